@@ -7,8 +7,11 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/totp"
 	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/spf13/cobra"
 )
@@ -21,7 +24,14 @@ import (
 // The command supports the following flags:
 //   - name (-n): The name/identifier of the secret (required in non-interactive mode)
 //   - value (-v): The secret value to store (or use -g to generate)
-//   - category (-c): Optional category for organizing secrets
+//   - category (-c): Optional category folder for organizing secrets (e.g. work/aws/prod)
+//   - tag: Optional tag for filtering secrets (repeatable)
+//   - username (-u): Optional username associated with the secret
+//   - url: Optional URL associated with the secret (repeatable)
+//   - notes: Optional free-form notes
+//   - field (-f): Optional custom field as [type:]name=value (repeatable)
+//   - totp: Treat the value as a TOTP seed (implied for otpauth:// URIs)
+//   - totp-algorithm, totp-digits, totp-period: TOTP parameters for raw seeds
 //   - generate (-g): Generate a random password (overrides -v)
 //   - length (-l): Length of generated password (default: 16)
 //   - symbols (-s): Include symbols in generated password
@@ -45,39 +55,45 @@ Examples:
   # Add with specific value and category
   vlxck add -n example.com -v newpassword -c work
 
+  # Add into a nested folder with tags
+  vlxck add -n aws-root -g -c work/aws/prod --tag prod --tag critical
+
   # Generate a 24-char password with symbols and digits
-  vlxck add -n example.com -gdsl 24`,
+  vlxck add -n example.com -gdsl 24
+
+  # Add with username, URL and custom fields
+  vlxck add -n github -g -u octocat --url https://github.com -f hidden:recovery=abcd-efgh -f email:contact=me@example.com
+
+  # Add a TOTP seed from an otpauth:// URI or as a raw base32 seed
+  vlxck add -n github-2fa -V 'otpauth://totp/GitHub:octocat?secret=JBSWY3DPEHPK3PXP&issuer=GitHub'
+  vlxck add -n aws-2fa --totp -V JBSWY3DPEHPK3PXP --totp-digits 8`,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get store path and check for interactive mode
 		filePath := getStorePath()
 		interactive, _ := cmd.Flags().GetBool("interactive")
 
-		// Get master password
-		password, err := getPassword(false)
-		if err != nil {
-			fmt.Println("Error:", err)
-			return
-		}
-
-		// Load or initialize the store
-		s, err := store.LoadStore(filePath, password)
-		if err == nil {
-			// Only cache the password if it was successfully used
-			cacheVerifiedPassword(password)
-		}
-		if err != nil {
-			if os.IsNotExist(err) {
-				if err := store.InitializeStore(filePath, password); err != nil {
-					fmt.Println("Error initializing store:", err)
-					return
-				}
-				s, err = store.LoadStore(filePath, password)
-				if err != nil {
-					fmt.Println("Error loading store:", err)
-					return
-				}
-			} else {
+		// Initialize the store on first use, otherwise unlock it
+		var s *store.Store
+		var key *store.Key
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			password, err := getPassword()
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			if err := store.InitializeStore(filePath, password); err != nil {
+				fmt.Println("Error initializing store:", err)
+				return
+			}
+			s, key, err = unlockWithPassword(filePath, password)
+			if err != nil {
+				fmt.Println("Error loading store:", err)
+				return
+			}
+		} else {
+			s, key, err = unlockStore(filePath)
+			if err != nil {
 				fmt.Println("Error loading store:", err)
 				return
 			}
@@ -85,15 +101,15 @@ Examples:
 
 		// Route to appropriate handler
 		if interactive {
-			addInteractive(s, filePath, password)
+			addInteractive(s, filePath, key)
 		} else {
-			addNonInteractive(cmd, s, filePath, password)
+			addNonInteractive(cmd, s, filePath, key)
 		}
 	},
 }
 
 // addInteractive handles the interactive add flow
-func addInteractive(s *store.Store, filePath, password string) {
+func addInteractive(s *store.Store, filePath string, key *store.Key) {
 	// Get secret details from user
 	name, err := utils.PromptForSecretName(s.Secrets)
 	if err != nil {
@@ -113,24 +129,85 @@ func addInteractive(s *store.Store, filePath, password string) {
 		return
 	}
 
-	// Add the new secret
-	s.Secrets = append(s.Secrets, store.Secret{
+	tags, err := utils.PromptForTags(nil)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	username, err := utils.PromptForInput("Enter username (optional)", "", nil)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	urls, err := utils.PromptForURLs(nil)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	notes, err := utils.PromptForInput("Enter notes (optional)", "", nil)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	secret := store.Secret{
 		Name:     name,
 		Value:    value,
 		Category: category,
-	})
+		Tags:     tags,
+		Username: username,
+		URLs:     urls,
+		Notes:    notes,
+	}
+
+	// An otpauth:// URI entered as the value makes this a TOTP secret
+	if strings.HasPrefix(secret.Value, "otpauth://") {
+		if err := setupTOTP(&secret, totp.DefaultParams()); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+
+	// Collect any number of custom fields
+	for {
+		more, err := utils.PromptForConfirm("Add a custom field")
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if !more {
+			break
+		}
+		field, err := utils.PromptForCustomField()
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		secret.SetField(field)
+	}
+
+	// Add the new secret
+	now := time.Now()
+	secret.CreatedAt = now
+	secret.UpdatedAt = now
+	s.Secrets = append(s.Secrets, secret)
 
 	// Save the updated store
-	if err := store.SaveStore(filePath, password, s); err != nil {
+	snapshotStore(filePath, "add")
+	if err := store.SaveStoreWithKey(filePath, key, s); err != nil {
 		fmt.Println("Error saving store:", err)
 		return
 	}
 
+	recordChange(filePath, "add")
 	fmt.Printf("Secret '%s' added successfully\n", name)
 }
 
 // addNonInteractive handles the non-interactive add flow using command-line flags
-func addNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password string) {
+func addNonInteractive(cmd *cobra.Command, s *store.Store, filePath string, key *store.Key) {
 	// Parse command-line flags
 	name, _ := cmd.Flags().GetString("name")
 	value, _ := cmd.Flags().GetString("value")
@@ -139,6 +216,12 @@ func addNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password st
 	symbols, _ := cmd.Flags().GetBool("symbols")
 	digits, _ := cmd.Flags().GetBool("digits")
 	category, _ := cmd.Flags().GetString("category")
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	username, _ := cmd.Flags().GetString("username")
+	urls, _ := cmd.Flags().GetStringSlice("url")
+	notes, _ := cmd.Flags().GetString("notes")
+	fieldSpecs, _ := cmd.Flags().GetStringArray("field")
+	isTOTP, _ := cmd.Flags().GetBool("totp")
 
 	// Validate required parameters
 	if name == "" {
@@ -151,6 +234,11 @@ func addNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password st
 		return
 	}
 
+	if isTOTP && generate {
+		fmt.Println("Error: --totp cannot be combined with --generate")
+		return
+	}
+
 	// Check for existing secret with the same name
 	for _, secret := range s.Secrets {
 		if secret.Name == name {
@@ -159,38 +247,70 @@ func addNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password st
 		}
 	}
 
+	secret := store.Secret{
+		Name:     name,
+		Category: store.NormalizeFolder(category),
+		Tags:     store.NormalizeTags(tags),
+		Username: username,
+		URLs:     urls,
+		Notes:    notes,
+	}
+
+	// Parse custom fields before generating anything
+	for _, spec := range fieldSpecs {
+		field, err := store.ParseCustomField(spec)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		secret.SetField(field)
+	}
+
 	// Generate or use provided value
-	var secretValue string
 	if generate {
 		var err error
-		secretValue, err = utils.GeneratePassword(length, symbols, digits)
+		secret.Value, err = utils.GeneratePassword(length, symbols, digits)
 		if err != nil {
 			fmt.Println("Error generating password:", err)
 			return
 		}
 		// Copy generated password to clipboard
-		if err := utils.CopyToClipboard(secretValue); err != nil {
+		if err := utils.CopyToClipboard(secret.Value); err != nil {
 			fmt.Println("Warning: Could not copy to clipboard:", err)
 		} else {
 			fmt.Println("Generated password copied to clipboard.")
 		}
 	} else {
-		secretValue = value
+		secret.Value = value
+	}
+
+	// Store TOTP seeds in canonical form along with their parameters
+	if isTOTP || strings.HasPrefix(secret.Value, "otpauth://") {
+		params, err := totpParamsFromFlags(cmd)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if err := setupTOTP(&secret, params); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
 	}
 
 	// Add the new secret
-	s.Secrets = append(s.Secrets, store.Secret{
-		Name:     name,
-		Value:    secretValue,
-		Category: category,
-	})
+	now := time.Now()
+	secret.CreatedAt = now
+	secret.UpdatedAt = now
+	s.Secrets = append(s.Secrets, secret)
 
 	// Save the updated store
-	if err := store.SaveStore(filePath, password, s); err != nil {
+	snapshotStore(filePath, "add")
+	if err := store.SaveStoreWithKey(filePath, key, s); err != nil {
 		fmt.Println("Error saving store:", err)
 		return
 	}
 
+	recordChange(filePath, "add")
 	fmt.Printf("Secret '%s' added successfully\n", name)
 }
 
@@ -202,7 +322,20 @@ func init() {
 	// Core flags
 	addCmd.Flags().StringP("name", "n", "", "Name of the secret (required in non-interactive mode)")
 	addCmd.Flags().StringP("value", "V", "", "Value of the secret (or use -g to generate)")
-	addCmd.Flags().StringP("category", "c", "", "Category for organizing secrets")
+	addCmd.Flags().StringP("category", "c", "", "Category folder for organizing secrets, e.g. work/aws/prod")
+	addCmd.Flags().StringSlice("tag", nil, "Tag for filtering secrets (repeatable or comma-separated)")
+
+	// Metadata flags
+	addCmd.Flags().StringP("username", "u", "", "Username associated with the secret")
+	addCmd.Flags().StringSlice("url", nil, "URL associated with the secret (repeatable)")
+	addCmd.Flags().String("notes", "", "Free-form notes")
+	addCmd.Flags().StringArrayP("field", "f", nil, "Custom field as [type:]name=value, type is text, hidden, url or email (repeatable)")
+
+	// TOTP flags
+	addCmd.Flags().Bool("totp", false, "Treat the value as a base32 TOTP seed (implied for otpauth:// URIs)")
+	addCmd.Flags().String("totp-algorithm", "SHA1", "TOTP hash algorithm: SHA1, SHA256 or SHA512")
+	addCmd.Flags().Int("totp-digits", 6, "Number of digits in TOTP codes (6 or 8)")
+	addCmd.Flags().Int("totp-period", 30, "TOTP code lifetime in seconds")
 
 	// Password generation flags
 	addCmd.Flags().BoolP("generate", "g", false, "Generate a random password")
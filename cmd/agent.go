@@ -0,0 +1,170 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'agent' command which is used to
+// keep the store unlocked in memory for a session.
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/agent"
+	"github.com/spf13/cobra"
+)
+
+// agentCmd represents the 'agent' command that runs the unlock agent.
+// The agent keeps the key derived from the master password in memory and
+// serves it to other vlxck commands over a Unix socket that only the current
+// user can access. Keys are dropped after the idle timeout or by 'vlxck lock'.
+//
+// The command supports the following flags:
+//   - idle-timeout: How long keys are kept without being used (default: 15m)
+//   - detach (-d): Start the agent in the background and return
+//   - status: Show whether the agent is running and which stores it holds keys for
+//   - stop: Stop a running agent
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run the unlock agent that keeps the store unlocked",
+	Long: `Run the unlock agent. After the master password has been entered once,
+other vlxck commands get the derived store key from the agent instead of prompting.
+The master password itself is never stored, and nothing is written to disk.
+
+The agent listens on a Unix socket with 0600 permissions and only answers
+processes running as the same user. Keys are dropped when they have not been
+used for the idle timeout, when 'vlxck lock' is run, or when the agent stops.
+The agent is available on Linux and macOS.
+
+Examples:
+  # Run the agent in the foreground
+  vlxck agent
+
+  # Start the agent in the background with a 1 hour idle timeout
+  vlxck agent -d --idle-timeout 1h
+
+  # Show the agent status
+  vlxck agent --status
+
+  # Stop the agent
+  vlxck agent --stop`,
+	Run: func(cmd *cobra.Command, args []string) {
+		idleTimeout, _ := cmd.Flags().GetDuration("idle-timeout")
+		detach, _ := cmd.Flags().GetBool("detach")
+		status, _ := cmd.Flags().GetBool("status")
+		stop, _ := cmd.Flags().GetBool("stop")
+
+		socketPath := agent.SocketPath()
+		client := agent.NewClient(socketPath)
+
+		switch {
+		case status:
+			printAgentStatus(client, socketPath)
+			return
+		case stop:
+			if err := client.Stop(); err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			fmt.Println("Agent stopped.")
+			return
+		}
+
+		if !agent.Supported {
+			fmt.Println("Error: the agent is not supported on this platform")
+			return
+		}
+		if idleTimeout <= 0 {
+			fmt.Println("Error: idle timeout must be positive")
+			return
+		}
+
+		if detach {
+			startDetachedAgent(client, idleTimeout)
+			return
+		}
+
+		server := agent.NewServer(idleTimeout)
+		if err := server.Listen(socketPath); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		// Take over SIGINT/SIGTERM from the root handler so the socket is
+		// removed and the keys are wiped before exiting
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			server.Close()
+		}()
+
+		fmt.Printf("Agent listening on %s (keys are dropped after %s idle).\n", socketPath, idleTimeout)
+		if err := server.Serve(); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Println("Agent stopped.")
+	},
+}
+
+// startDetachedAgent starts the agent as a background process and waits
+// until it accepts connections.
+func startDetachedAgent(client *agent.Client, idleTimeout time.Duration) {
+	if client.Running() {
+		fmt.Println("Agent is already running.")
+		return
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	child := exec.Command(executable, "agent", "--idle-timeout", idleTimeout.String())
+	child.SysProcAttr = agent.DetachAttr()
+	if err := child.Start(); err != nil {
+		fmt.Println("Error starting agent:", err)
+		return
+	}
+
+	for i := 0; i < 50; i++ {
+		if client.Running() {
+			fmt.Printf("Agent started (pid %d).\n", child.Process.Pid)
+			child.Process.Release()
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	fmt.Println("Error: agent did not start")
+}
+
+// printAgentStatus prints whether the agent is running and what it holds.
+func printAgentStatus(client *agent.Client, socketPath string) {
+	status, err := client.Status()
+	if err != nil {
+		fmt.Printf("Agent is not running (socket: %s).\n", socketPath)
+		return
+	}
+	fmt.Printf("Agent is running (pid %d, socket: %s).\n", status.PID, socketPath)
+	if len(status.Stores) == 0 {
+		fmt.Println("Locked: no keys held.")
+		return
+	}
+	fmt.Printf("Unlocked stores (locking in %s):\n", status.LockIn)
+	for _, path := range status.Stores {
+		fmt.Printf("  %s\n", path)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+
+	// Define command flags with shorthand and descriptions
+	agentCmd.Flags().Duration("idle-timeout", 15*time.Minute, "Drop keys after they have not been used for this long")
+	agentCmd.Flags().BoolP("detach", "d", false, "Start the agent in the background")
+	agentCmd.Flags().Bool("status", false, "Show the agent status")
+	agentCmd.Flags().Bool("stop", false, "Stop the running agent")
+}
@@ -0,0 +1,181 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the automatic synchronization which, when enabled with
+// 'vlxck sync --auto', pulls the store before commands that use it and pushes
+// it after every successful save.
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kirinyoku/vlxck/internal/agent"
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// autoSyncCommands are the commands that read or modify the store. With
+// auto-sync enabled, the store is pulled before they run.
+var autoSyncCommands = map[string]bool{
+	"add":           true,
+	"change-master": true,
+	"delete":        true,
+	"export":        true,
+	"get":           true,
+	"history":       true,
+	"import":        true,
+	"inject":        true,
+	"list":          true,
+	"otp":           true,
+	"rekey":         true,
+	"rollback":      true,
+	"run":           true,
+	"search":        true,
+	"update":        true,
+}
+
+// setAutoSync turns auto-sync on or off in the config.
+//
+// Parameters:
+//   - auto: Whether to sync automatically
+//
+// Returns:
+//   - error: An error if sync is not initialized or the config cannot be saved
+func setAutoSync(auto bool) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	if auto && cfg.Sync.Provider == "" {
+		return fmt.Errorf("sync is not initialized; run 'vlxck sync --init' first")
+	}
+
+	cfg.Sync.Auto = auto
+	if err := config.SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	if auto {
+		fmt.Printf("Automatic sync with %s enabled.\n", cfg.Sync.Provider)
+	} else {
+		fmt.Println("Automatic sync disabled.")
+	}
+	return nil
+}
+
+// autoSyncManager returns a sync manager for the store if auto-sync is
+// enabled, or nil. The master password is only asked for if the provider
+// keeps credentials or no store key is cached for merging. The manager never
+// asks about conflicts or for credentials of the remote: auto-sync runs around
+// commands whose output may be read by scripts, so a merge or a git remote
+// that needs the user fails instead.
+func autoSyncManager(filePath string) *sync.SyncManager {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load config: %v\n", err)
+		return nil
+	}
+	if !cfg.Sync.Auto || cfg.Sync.Provider == "" {
+		return nil
+	}
+
+	key := cachedKey(filePath)
+	password, err := syncPassword(cfg.Sync.Provider, key == nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Auto-sync skipped: %v\n", err)
+		return nil
+	}
+
+	manager, err := sync.NewSyncManager(context.Background(), filePath, password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Auto-sync skipped: %v\n", err)
+		return nil
+	}
+	manager.UseKey(key)
+	manager.NoPrompt()
+	return manager
+}
+
+// syncPassword returns the master password for a sync manager, asking for it
+// only if the provider keeps credentials encrypted with it or the store has
+// to be decrypted without a cached key. Otherwise it returns "".
+//
+// Parameters:
+//   - provider: The configured sync provider
+//   - needKey: Whether the store may have to be decrypted (merged) without a key
+//
+// Returns:
+//   - string: The master password, or "" if it is not needed
+//   - error: An error if the password cannot be read
+func syncPassword(provider string, needKey bool) (string, error) {
+	if !sync.UsesCredentials(provider) && !needKey {
+		return "", nil
+	}
+	return getPassword()
+}
+
+// autoPull pulls the store before a command that uses it, then pushes any
+// changes a previous command could not push, e.g. while offline. Failures,
+// including conflicts that would need the user to choose a version, are
+// reported as warnings on stderr so that the command still runs on the local
+// store and its output stays clean.
+func autoPull(cmd *cobra.Command) {
+	if cmd.Parent() != cmd.Root() || !autoSyncCommands[cmd.Name()] {
+		return
+	}
+	filePath := getStorePath()
+	manager := autoSyncManager(filePath)
+	if manager == nil {
+		return
+	}
+
+	err := manager.Sync(sync.ModePull)
+	switch {
+	case errors.Is(err, sync.ErrConflicts):
+		fmt.Fprintln(os.Stderr, "Warning: Auto-sync skipped the pull: secrets were changed both locally and on the remote. Run 'vlxck sync -m pull' to resolve the conflicts.")
+		return
+	case err != nil && !errors.Is(err, sync.ErrNoRemote):
+		fmt.Fprintf(os.Stderr, "Warning: Auto-sync could not pull the store: %v\n", err)
+		return
+	}
+
+	pending, err := manager.LocalChanges()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Auto-sync could not check for local changes: %v\n", err)
+		return
+	}
+	if pending {
+		pushStore(manager)
+	}
+}
+
+// autoPush pushes the store after a successful save
+func autoPush(filePath string) {
+	if manager := autoSyncManager(filePath); manager != nil {
+		pushStore(manager)
+	}
+}
+
+// pushStore pushes the store, leaving it for the next command to push if
+// that fails: the store stays ahead of the last sync until it is pushed.
+func pushStore(manager *sync.SyncManager) {
+	if err := manager.Sync(sync.ModePush); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Auto-sync could not push the store, the next command will retry: %v\n", err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Auto-sync: store pushed.")
+}
+
+// cachedKey returns the store key held by the unlock agent or the key cache,
+// or nil if neither has one. The key is not verified against the store.
+func cachedKey(filePath string) *store.Key {
+	if key, err := agent.NewClient(agent.SocketPath()).GetKey(filePath); err == nil && key != nil {
+		return key
+	}
+	if key, err := keyCache().Get(filePath); err == nil {
+		return key
+	}
+	return nil
+}
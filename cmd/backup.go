@@ -5,19 +5,40 @@ import (
 	"path/filepath"
 
 	"github.com/kirinyoku/vlxck/internal/backup"
+	"github.com/kirinyoku/vlxck/internal/crypto"
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/spf13/cobra"
 )
 
 // backupCmd represents the backup command.
-// It creates a timestamped backup of the secret store in the specified directory.
+// It creates a timestamped, encrypted backup of the secret store in the specified directory.
 // If no directory is provided, backups will be stored in ~/.vlxck/backups.
 var backupCmd = &cobra.Command{
 	Use:   "backup [backup-dir]",
-	Short: "Create a backup of the secret store",
+	Short: "Create an encrypted backup of the secret store",
 	Long: `Create a timestamped backup of the secret store in the specified directory.
-If no directory is provided, backups will be stored in ~/.vlxck/backups.`,
+If no directory is provided, backups will be stored in ~/.vlxck/backups.
+
+The backup contains the store and the config file and is encrypted with the
+master password, or with a separate backup passphrase if --passphrase is given.
+
+Examples:
+  # Back up to the default location
+  vlxck backup
+
+  # Back up with a passphrase instead of the master password
+  vlxck backup --passphrase
+
+  # Delete old backups according to the retention policy
+  vlxck backup prune`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		usePassphrase, err := cmd.Flags().GetBool("passphrase")
+		if err != nil {
+			return fmt.Errorf("failed to get passphrase flag: %w", err)
+		}
+
 		storePath := getStorePath()
 		backupDir := filepath.Join(filepath.Dir(storePath), "backups")
 
@@ -25,7 +46,18 @@ If no directory is provided, backups will be stored in ~/.vlxck/backups.`,
 			backupDir = args[0]
 		}
 
-		backupFile, err := backup.Backup(filepath.Dir(storePath), backupDir)
+		var key *store.Key
+		if usePassphrase {
+			key, err = backupPassphraseKey(storePath)
+		} else {
+			// Unlocking the store verifies the master password before it encrypts the backup
+			_, key, err = unlockStore(storePath)
+		}
+		if err != nil {
+			return err
+		}
+
+		backupFile, err := backup.Backup(filepath.Dir(storePath), backupDir, key)
 		if err != nil {
 			return fmt.Errorf("backup failed: %w", err)
 		}
@@ -41,6 +73,38 @@ If no directory is provided, backups will be stored in ~/.vlxck/backups.`,
 	},
 }
 
+// backupPassphraseKey prompts for a new backup passphrase and derives a key
+// for it with a fresh salt and the KDF cost of the store.
+//
+// Parameters:
+//   - storePath: Path to the store whose KDF parameters to use
+//
+// Returns:
+//   - *store.Key: The key to encrypt the backup with
+//   - error: An error if the passphrase is empty or was not confirmed
+func backupPassphraseKey(storePath string) (*store.Key, error) {
+	passphrase := utils.PromptForPassword("Enter backup passphrase: ")
+	if passphrase == "" {
+		return nil, fmt.Errorf("backup passphrase cannot be empty")
+	}
+	if utils.PromptForPassword("Confirm backup passphrase: ") != passphrase {
+		return nil, fmt.Errorf("backup passphrases do not match")
+	}
+
+	params := crypto.DefaultKDFParams
+	if current, err := store.ReadHeader(storePath); err == nil {
+		params = current.Params
+	}
+	header, err := store.NewHeader(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup header: %w", err)
+	}
+	return header.Key(passphrase), nil
+}
+
 func init() {
 	rootCmd.AddCommand(backupCmd)
+
+	// Define command flags with shorthand and descriptions
+	backupCmd.Flags().BoolP("passphrase", "p", false, "Encrypt the backup with a separate passphrase instead of the master password")
 }
@@ -0,0 +1,126 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'backup prune' command which is used to
+// delete old backups according to the retention policy.
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kirinyoku/vlxck/internal/backup"
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// backupPruneCmd represents the backup prune command
+// It deletes the backups that the retention policy from the config file, or
+// the --keep-* flags, does not keep.
+var backupPruneCmd = &cobra.Command{
+	Use:   "prune [backup-dir]",
+	Short: "Delete old backups according to the retention policy",
+	Long: `Delete the backups that the retention policy does not keep.
+
+A backup is kept if it is one of the newest --keep-last backups, or the newest
+backup of one of the newest --keep-daily days, --keep-weekly weeks or
+--keep-monthly months that have backups. The policy is read from the backup
+section of the config file; flags override it.
+
+Examples:
+  # Show which backups would be deleted
+  vlxck backup prune --dry-run
+
+  # Keep the last 5 backups and one per month for a year
+  vlxck backup prune --keep-last 5 --keep-daily 0 --keep-weekly 0 --keep-monthly 12`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return fmt.Errorf("failed to get dry-run flag: %w", err)
+		}
+
+		backupDir := filepath.Join(filepath.Dir(getStorePath()), "backups")
+		if len(args) > 0 {
+			backupDir = args[0]
+		}
+
+		policy, err := retentionPolicy(cmd)
+		if err != nil {
+			return err
+		}
+
+		var removed []backup.BackupInfo
+		if dryRun {
+			if policy.IsEmpty() {
+				return fmt.Errorf("the retention policy keeps no backups")
+			}
+			backups, err := backup.ListBackups(backupDir)
+			if err != nil {
+				return fmt.Errorf("failed to list backups: %w", err)
+			}
+			_, removed = policy.Apply(backups)
+		} else {
+			removed, err = backup.Prune(backupDir, policy)
+			for _, b := range removed {
+				fmt.Printf("Deleted %s\n", b.Name)
+			}
+			if err != nil {
+				return fmt.Errorf("prune failed: %w", err)
+			}
+		}
+
+		switch {
+		case len(removed) == 0:
+			fmt.Println("No backups to delete.")
+		case dryRun:
+			fmt.Println("Backups that would be deleted:")
+			for _, b := range removed {
+				fmt.Printf("  %s\n", b.Name)
+			}
+		default:
+			fmt.Printf("✓ Deleted %d backups\n", len(removed))
+		}
+		return nil
+	},
+}
+
+// retentionPolicy returns the retention policy from the config file with the
+// --keep-* flags that were given applied on top.
+func retentionPolicy(cmd *cobra.Command) (backup.Policy, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return backup.Policy{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	policy := backup.Policy{
+		KeepLast:    cfg.Backup.KeepLast,
+		KeepDaily:   cfg.Backup.KeepDaily,
+		KeepWeekly:  cfg.Backup.KeepWeekly,
+		KeepMonthly: cfg.Backup.KeepMonthly,
+	}
+
+	flags := map[string]*int{
+		"keep-last":    &policy.KeepLast,
+		"keep-daily":   &policy.KeepDaily,
+		"keep-weekly":  &policy.KeepWeekly,
+		"keep-monthly": &policy.KeepMonthly,
+	}
+	for name, value := range flags {
+		if !cmd.Flags().Changed(name) {
+			continue
+		}
+		if *value, err = cmd.Flags().GetInt(name); err != nil {
+			return backup.Policy{}, fmt.Errorf("failed to get %s flag: %w", name, err)
+		}
+	}
+	return policy, nil
+}
+
+func init() {
+	backupCmd.AddCommand(backupPruneCmd)
+
+	// Define command flags with shorthand and descriptions
+	backupPruneCmd.Flags().Int("keep-last", config.DefaultBackupKeepLast, "Number of newest backups to keep")
+	backupPruneCmd.Flags().Int("keep-daily", config.DefaultBackupKeepDaily, "Number of days to keep the newest backup of")
+	backupPruneCmd.Flags().Int("keep-weekly", config.DefaultBackupKeepWeekly, "Number of weeks to keep the newest backup of")
+	backupPruneCmd.Flags().Int("keep-monthly", config.DefaultBackupKeepMonthly, "Number of months to keep the newest backup of")
+	backupPruneCmd.Flags().Bool("dry-run", false, "Show which backups would be deleted without deleting them")
+}
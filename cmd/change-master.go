@@ -4,10 +4,12 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
-	"github.com/kirinyoku/vlxck/internal/cache"
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/crypto"
 	"github.com/kirinyoku/vlxck/internal/store"
 	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/spf13/cobra"
@@ -16,6 +18,7 @@ import (
 // changeMasterCmd represents the 'change-master' command that allows users to change the master password for the encrypted store.
 // It prompts the user for the current master password and the new master password.
 // If the new master password is successfully changed, it displays a message indicating that the master password was changed successfully.
+// Sync credentials kept in the config are re-encrypted with the new master password.
 //
 // The command requires the following flags:
 //   - filePath (-f): The path to the encrypted store file
@@ -24,16 +27,12 @@ var changeMasterCmd = &cobra.Command{
 	Short: "Change the master password",
 	Run: func(cmd *cobra.Command, args []string) {
 		filePath := getStorePath()
-		oldPassword, err := getPassword(false)
+		oldPassword, err := getPassword()
 		if err != nil {
 			fmt.Println("Error:", err)
 			return
 		}
 		s, err := store.LoadStore(filePath, oldPassword)
-		if err == nil {
-			// Only cache the password if it was successfully used
-			cacheVerifiedPassword(oldPassword)
-		}
 		if err != nil {
 			fmt.Println("Error loading store:", err)
 			return
@@ -44,14 +43,51 @@ var changeMasterCmd = &cobra.Command{
 			fmt.Println("Passwords do not match. Exiting.")
 			return
 		}
-		if err := store.SaveStore(filePath, newPassword, s); err != nil {
+		// Rotate the salt along with the password, keeping the current KDF cost
+		params := crypto.DefaultKDFParams
+		if current, err := store.ReadHeader(filePath); err == nil {
+			params = current.Params
+		}
+		header, err := store.NewHeader(params)
+		if err != nil {
+			fmt.Println("Error generating salt:", err)
+			return
+		}
+
+		// Sync credentials in the config are encrypted with the master
+		// password too; re-encrypt them before anything is written
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		previous := config.SyncCredentials(cfg)
+		saveCredentials, err := config.ReencryptCredentials(cfg, oldPassword, newPassword)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; run 'vlxck sync --init' after the change to set up sync again\n", err)
+		}
+
+		// Undo brings back the credentials encrypted with the old password along with the store
+		var attachment []byte
+		if saveCredentials {
+			if attachment, err = json.Marshal(previous); err != nil {
+				fmt.Println("Error saving sync credentials for undo:", err)
+				return
+			}
+		}
+		snapshotStoreWith(filePath, "change-master", attachment)
+		if err := store.SaveStoreWithHeader(filePath, newPassword, s, header); err != nil {
 			fmt.Println("Error saving store:", err)
 			return
 		}
-		// Clear the cached password since it's been changed
-		if err := cache.ClearMasterPassword(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to clear password cache: %v\n", err)
+		if saveCredentials {
+			if err := config.SaveConfig(cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to save the re-encrypted sync credentials: %v; run 'vlxck sync --init' again\n", err)
+			}
 		}
+		// Keys derived from the old password no longer match
+		forgetKey(filePath)
+		recordChange(filePath, "change-master")
 		fmt.Println("Master password changed successfully.")
 	},
 }
@@ -0,0 +1,253 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the CSV import and export used by 'vlxck import --format csv'
+// and 'vlxck export --format csv'.
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/csvio"
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// Formats accepted by the --format flag of import and export.
+const (
+	formatStore = "store" // An encrypted store file
+	formatCSV   = "csv"   // A plaintext CSV file
+)
+
+// csvExportFile is the name of the file written by 'vlxck export --format csv'.
+const csvExportFile = "secrets.csv"
+
+// transferFormat returns the format selected with --format.
+func transferFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	switch format {
+	case formatStore, formatCSV:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format '%s' (use %s or %s)", format, formatStore, formatCSV)
+}
+
+// importCSV merges the secrets of a CSV file into the store, creating the
+// store on first use. A secret that already exists with different contents
+// is a conflict and the user decides which version to keep; when the CSV
+// version is chosen, the current value stays in the history.
+//
+// Parameters:
+//   - filePath: Path to the encrypted store file
+//   - importPath: Path to the CSV file
+//   - mapping: Maps secret fields to CSV columns
+//   - unescape: Whether the file was written by 'vlxck export --format csv',
+//     whose formula escapes are removed
+func importCSV(filePath, importPath string, mapping csvio.Mapping, unescape bool) {
+	file, err := os.Open(importPath)
+	if err != nil {
+		fmt.Println("Error opening import file:", err)
+		return
+	}
+	imported, err := csvio.Read(file, mapping, unescape)
+	file.Close()
+	if err != nil {
+		fmt.Println("Error reading import file:", err)
+		return
+	}
+
+	var s *store.Store
+	var key *store.Key
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		password, err := getPassword()
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if err := store.InitializeStore(filePath, password); err != nil {
+			fmt.Println("Error initializing store:", err)
+			return
+		}
+		s, key, err = unlockWithPassword(filePath, password)
+		if err != nil {
+			fmt.Println("Error loading store:", err)
+			return
+		}
+	} else {
+		s, key, err = unlockStore(filePath)
+		if err != nil {
+			fmt.Println("Error loading store:", err)
+			return
+		}
+	}
+
+	importedCount, overwrittenCount, skippedCount := 0, 0, 0
+	for _, secret := range imported {
+		current := s.Find(secret.Name)
+		if current == nil {
+			s.Secrets = append(s.Secrets, secret)
+			importedCount++
+			continue
+		}
+		if sameCSVFields(*current, secret) {
+			skippedCount++
+			continue
+		}
+		if utils.PromptForConflictChoice(*current, secret) != "i" {
+			skippedCount++
+			continue
+		}
+		current.SetValue(secret.Value)
+		current.Category = secret.Category
+		current.Tags = secret.Tags
+		current.Username = secret.Username
+		current.URLs = secret.URLs
+		current.Notes = secret.Notes
+		current.UpdatedAt = time.Now()
+		importedCount++
+		overwrittenCount++
+	}
+
+	if importedCount == 0 {
+		fmt.Printf("No new or changed secrets in %s (%d skipped)\n", importPath, skippedCount)
+		return
+	}
+
+	snapshotStore(filePath, "import")
+	if err := store.SaveStoreWithKey(filePath, key, s); err != nil {
+		fmt.Println("Error saving store:", err)
+		return
+	}
+	recordChange(filePath, "import")
+	fmt.Printf("Successfully imported %d secrets (%d overwritten, %d skipped) from %s\n", importedCount, overwrittenCount, skippedCount, importPath)
+}
+
+// sameCSVFields reports whether two secrets agree in every field a CSV file holds.
+func sameCSVFields(a, b store.Secret) bool {
+	return a.Value == b.Value && a.Category == b.Category && a.Username == b.Username &&
+		a.Notes == b.Notes && reflect.DeepEqual(a.Tags, b.Tags) && reflect.DeepEqual(a.URLs, b.URLs)
+}
+
+// csvLeftOut describes the data of the secrets that CSV has no columns for:
+// TOTP settings, custom fields and history.
+//
+// Parameters:
+//   - secrets: The secrets to export
+//
+// Returns:
+//   - []string: What is left out, e.g. "history (2 secrets)" (empty if nothing)
+func csvLeftOut(secrets []store.Secret) []string {
+	var totpCount, fieldsCount, historyCount int
+	for _, secret := range secrets {
+		if secret.IsTOTP() {
+			totpCount++
+		}
+		if len(secret.Fields) > 0 {
+			fieldsCount++
+		}
+		if len(secret.History) > 0 {
+			historyCount++
+		}
+	}
+
+	var leftOut []string
+	for _, item := range []struct {
+		what  string
+		count int
+	}{
+		{"TOTP type and parameters", totpCount},
+		{"custom fields", fieldsCount},
+		{"history", historyCount},
+	} {
+		if item.count == 1 {
+			leftOut = append(leftOut, fmt.Sprintf("%s (1 secret)", item.what))
+		} else if item.count > 1 {
+			leftOut = append(leftOut, fmt.Sprintf("%s (%d secrets)", item.what, item.count))
+		}
+	}
+	return leftOut
+}
+
+// exportCSV writes the secrets matching the filter (all if it is empty) to a
+// plaintext CSV file in the directory, readable only by the owner. Unless yes
+// is set, the user has to confirm writing unencrypted secrets and replacing
+// an existing file. The file is written to a new temporary file and renamed
+// into place, so a file or symlink at the target path is replaced, never
+// written through.
+//
+// Parameters:
+//   - storePath: Path to the encrypted store file
+//   - dir: The directory to write the CSV file to
+//   - filter: Selects the secrets to export
+//   - mapping: Maps secret fields to CSV header names
+//   - yes: Whether writing plaintext was already confirmed
+func exportCSV(storePath, dir string, filter store.Filter, mapping csvio.Mapping, yes bool) {
+	s, _, err := unlockStore(storePath)
+	if err != nil {
+		fmt.Println("Error loading store:", err)
+		return
+	}
+
+	secrets := filter.Apply(s.Secrets)
+	if len(secrets) == 0 {
+		if filter.IsEmpty() {
+			fmt.Println("No secrets to export.")
+		} else {
+			fmt.Printf("No secrets found for %s.\n", filter)
+		}
+		return
+	}
+
+	// Check the mapping before asking, so that nothing is written if it is invalid
+	if _, err := mapping.Header(); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	if leftOut := csvLeftOut(secrets); len(leftOut) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: CSV has no columns for %s; they are left out of the export.\n", strings.Join(leftOut, ", "))
+	}
+
+	targetPath := filepath.Join(dir, csvExportFile)
+	if !yes {
+		fmt.Printf("WARNING: %s will contain %d secrets in plaintext, readable by anyone who gets the file.\n", targetPath, len(secrets))
+		confirm, err := utils.PromptForConfirm("Write the secrets unencrypted")
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if !confirm {
+			fmt.Println("Export cancelled.")
+			return
+		}
+
+		if _, err := os.Lstat(targetPath); err == nil {
+			confirm, err := utils.PromptForConfirm(fmt.Sprintf("%s already exists. Overwrite it", targetPath))
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			if !confirm {
+				fmt.Println("Export cancelled.")
+				return
+			}
+		}
+	}
+
+	var data bytes.Buffer
+	if err := csvio.Write(&data, secrets, mapping); err != nil {
+		fmt.Println("Error writing export file:", err)
+		return
+	}
+	if err := utils.WriteFileAtomic(targetPath, data.Bytes(), 0600); err != nil {
+		fmt.Println("Error writing export file:", err)
+		return
+	}
+
+	fmt.Printf("Exported %d secrets in plaintext to %s\n", len(secrets), targetPath)
+}
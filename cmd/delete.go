@@ -25,16 +25,7 @@ Examples:
   vlxck delete -n example.com`,
 	Run: func(cmd *cobra.Command, args []string) {
 		filePath := getStorePath()
-		password, err := getPassword(false)
-		if err != nil {
-			fmt.Println("Error:", err)
-			return
-		}
-		s, err := store.LoadStore(filePath, password)
-		if err == nil {
-			// Only cache the password if it was successfully used
-			cacheVerifiedPassword(password)
-		}
+		s, key, err := unlockStore(filePath)
 		if err != nil {
 			fmt.Println("Error loading store:", err)
 			return
@@ -43,34 +34,29 @@ Examples:
 		// Check for interactive mode
 		interactive, _ := cmd.Flags().GetBool("interactive")
 		if interactive {
-			deleteInteractive(s, filePath, password)
+			deleteInteractive(s, filePath, key)
 			return
 		}
 
 		// Non-interactive mode
-		deleteNonInteractive(cmd, s, filePath, password)
+		deleteNonInteractive(cmd, s, filePath, key)
 	},
 }
 
 // deleteInteractive handles the interactive delete flow
-func deleteInteractive(s *store.Store, filePath, password string) {
+func deleteInteractive(s *store.Store, filePath string, key *store.Key) {
 	if len(s.Secrets) == 0 {
 		fmt.Println("No secrets found to delete.")
 		return
 	}
 
-	// Create a list of secret names for selection
-	secretNames := make([]string, 0, len(s.Secrets))
-	for _, secret := range s.Secrets {
-		secretNames = append(secretNames, secret.Name)
-	}
-
-	// Prompt user to select a secret to delete
-	selectedName, err := utils.PromptForSelect("Select secret to delete", secretNames)
+	// Prompt user to select a secret to delete; typing filters the list
+	selected, err := utils.PromptForSecret("Select secret to delete", s.Secrets)
 	if err != nil {
 		fmt.Println("Error selecting secret:", err)
 		return
 	}
+	selectedName := selected.Name
 
 	// Confirm deletion
 	confirm, err := utils.PromptForConfirm(fmt.Sprintf("Are you sure you want to delete '%s'?", selectedName))
@@ -85,37 +71,34 @@ func deleteInteractive(s *store.Store, filePath, password string) {
 	}
 
 	// Find and delete the selected secret
-	for i, secret := range s.Secrets {
-		if secret.Name == selectedName {
-			s.Secrets = append(s.Secrets[:i], s.Secrets[i+1:]...)
-			if err := store.SaveStore(filePath, password, s); err != nil {
-				fmt.Println("Error saving store:", err)
-				return
-			}
-			fmt.Printf("Secret '%s' deleted successfully.\n", selectedName)
+	if s.Delete(selectedName) {
+		snapshotStore(filePath, "delete")
+		if err := store.SaveStoreWithKey(filePath, key, s); err != nil {
+			fmt.Println("Error saving store:", err)
 			return
 		}
+		recordChange(filePath, "delete")
+		fmt.Printf("Secret '%s' deleted successfully.\n", selectedName)
 	}
 }
 
 // deleteNonInteractive handles the non-interactive delete flow
-func deleteNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password string) {
+func deleteNonInteractive(cmd *cobra.Command, s *store.Store, filePath string, key *store.Key) {
 	name, _ := cmd.Flags().GetString("name")
 	if name == "" {
 		fmt.Println("Error: secret name is required in non-interactive mode")
 		return
 	}
 
-	for i, secret := range s.Secrets {
-		if secret.Name == name {
-			s.Secrets = append(s.Secrets[:i], s.Secrets[i+1:]...)
-			if err := store.SaveStore(filePath, password, s); err != nil {
-				fmt.Println("Error saving store:", err)
-				return
-			}
-			fmt.Printf("Secret '%s' deleted successfully.\n", name)
+	if s.Delete(name) {
+		snapshotStore(filePath, "delete")
+		if err := store.SaveStoreWithKey(filePath, key, s); err != nil {
+			fmt.Println("Error saving store:", err)
 			return
 		}
+		recordChange(filePath, "delete")
+		fmt.Printf("Secret '%s' deleted successfully.\n", name)
+		return
 	}
 	fmt.Printf("Secret '%s' not found.\n", name)
 }
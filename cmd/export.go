@@ -9,6 +9,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/kirinyoku/vlxck/internal/csvio"
+	"github.com/kirinyoku/vlxck/internal/store"
 	"github.com/spf13/cobra"
 )
 
@@ -18,15 +20,53 @@ import (
 // If the directory to export the store file to does not exist, it creates the directory.
 // If the store file is successfully exported, it displays a message indicating that the store file was exported successfully.
 //
+// When a category or tag filter is given, only the matching secrets are written
+// to a new encrypted store protected by the same master password.
+//
 // The command requires the following flags:
 //   - dir (-d): The directory to export the store file to (required)
+//   - category (-c): Only export secrets in this category folder (optional)
+//   - tag: Only export secrets matching this tag expression (optional, repeatable)
+//   - format: store (default) or csv (optional)
+//   - map: Column names for CSV export, as field=column pairs (optional)
+//   - yes (-y): Skip the confirmations for plaintext CSV export (optional)
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export the store to a specified directory",
+	Long: `Export the store to a specified directory.
+
+By default the encrypted store file is copied to store.dat in the directory,
+or, with a category or tag filter, an encrypted store holding only the matching
+secrets is written. With --format csv, the secrets are written to secrets.csv
+in plaintext instead, readable only by you; this, and replacing an existing
+secrets.csv, has to be confirmed unless --yes is given. --map renames columns,
+e.g. --map name=title,value=password.
+
+Examples:
+  # Copy the encrypted store
+  vlxck export -d ~/exports
+
+  # Export work secrets as CSV for a spreadsheet
+  vlxck export -d ~/exports -c work --format csv
+
+  # Export CSV with the column names another password manager expects
+  vlxck export -d ~/exports --format csv --map name=title,category=group`,
 	Run: func(cmd *cobra.Command, args []string) {
 		storePath := getStorePath()
 		dir, _ := cmd.Flags().GetString("dir")
 
+		format, err := transferFormat(cmd)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		mapSpec, _ := cmd.Flags().GetString("map")
+		mapping, err := csvio.ParseMapping(mapSpec)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
 		if _, err := os.Stat(storePath); os.IsNotExist(err) {
 			fmt.Println("Error: store file does not exist")
 			return
@@ -39,6 +79,21 @@ var exportCmd = &cobra.Command{
 
 		targetPath := filepath.Join(dir, "store.dat")
 
+		filter, err := secretFilterFromFlags(cmd)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if format == formatCSV {
+			yes, _ := cmd.Flags().GetBool("yes")
+			exportCSV(storePath, dir, filter, mapping, yes)
+			return
+		}
+		if !filter.IsEmpty() {
+			exportFiltered(storePath, targetPath, filter)
+			return
+		}
+
 		sourceFile, err := os.Open(storePath)
 		if err != nil {
 			fmt.Println("Error opening store file:", err)
@@ -67,12 +122,51 @@ var exportCmd = &cobra.Command{
 	},
 }
 
+// exportFiltered writes an encrypted store containing only the secrets matching
+// the filter. The export is encrypted with the same key as the current store,
+// so it opens with the same master password.
+func exportFiltered(storePath, targetPath string, filter store.Filter) {
+	s, key, err := unlockStore(storePath)
+	if err != nil {
+		fmt.Println("Error loading store:", err)
+		return
+	}
+
+	secrets := filter.Apply(s.Secrets)
+	if len(secrets) == 0 {
+		fmt.Printf("No secrets found for %s.\n", filter)
+		return
+	}
+
+	// Replace any previous export, which may have a different salt
+	if err := os.Remove(targetPath); err != nil && !os.IsNotExist(err) {
+		fmt.Println("Error replacing export file:", err)
+		return
+	}
+	exported := &store.Store{Version: s.Version, Secrets: secrets}
+	if err := store.SaveStoreWithKey(targetPath, key, exported); err != nil {
+		fmt.Println("Error writing export file:", err)
+		return
+	}
+
+	fmt.Printf("Exported %d secrets (%s) to %s\n", len(secrets), filter, targetPath)
+}
+
 func init() {
 	rootCmd.AddCommand(exportCmd)
 
 	// Directory to export store file to
 	exportCmd.Flags().StringP("dir", "d", "", "Directory to export store file to (required)")
 
+	// Optional filters; when set, only matching secrets are exported
+	exportCmd.Flags().StringP("category", "c", "", "Only export secrets in this category folder (includes subfolders)")
+	exportCmd.Flags().StringSlice("tag", nil, "Only export secrets with this tag, prefix with ! to exclude (repeatable or comma-separated)")
+
+	// Plaintext CSV export
+	exportCmd.Flags().String("format", formatStore, "Export format: store (encrypted) or csv (plaintext)")
+	exportCmd.Flags().String("map", "", "CSV column names as field=column pairs, e.g. name=title,value=password")
+	exportCmd.Flags().BoolP("yes", "y", false, "Skip the confirmations for writing a plaintext CSV file")
+
 	// Mark required flags
 	exportCmd.MarkFlagRequired("dir")
 }
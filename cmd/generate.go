@@ -5,7 +5,9 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/kirinyoku/vlxck/internal/output"
 	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/spf13/cobra"
 )
@@ -13,6 +15,8 @@ import (
 // generateCmd represents the 'generate' command that allows users to generate random passwords.
 // It prompts the user for the password length and whether to include symbols and numbers.
 // If the password is successfully generated, it copies it to the clipboard.
+// With --output json|yaml|tsv, the result is also printed; the password is
+// only included with --reveal.
 //
 // The command requires the following flags:
 //   - length (-l): The desired length of the password
@@ -21,24 +25,58 @@ import (
 var generateCmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate a random password",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Generate a random password and copy it to the clipboard.
+
+Examples:
+  # Generate a 24-character password with symbols and digits
+  vlxck generate -l 24 -s -d
+
+  # Print the generated password as JSON for scripts
+  vlxck generate --output json --reveal`,
+	// Errors go to stderr with a non-zero exit code, as for get
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		length, _ := cmd.Flags().GetInt("length")
 		symbols, _ := cmd.Flags().GetBool("symbols")
 		digits, _ := cmd.Flags().GetBool("digits")
 		password, err := utils.GeneratePassword(length, symbols, digits)
 		if err != nil {
-			fmt.Println("Error generating password:", err)
-			return
+			return fmt.Errorf("failed to generate password: %w", err)
+		}
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
 		}
+		if format.IsStructured() {
+			return generateStructured(cmd, format, password, length, symbols, digits)
+		}
+
 		err = utils.CopyToClipboard(password)
 		if err != nil {
 			fmt.Printf("Generated password: %s (clipboard error: %v)\n", password, err)
-			return
+			return nil
 		}
 		fmt.Println("Password generated and copied to clipboard.")
+		return nil
 	},
 }
 
+// generateStructured copies the password to the clipboard and prints the result
+// in a machine-readable format. The password itself is only included with --reveal.
+func generateStructured(cmd *cobra.Command, format output.Format, password string, length int, symbols, digits bool) error {
+	result := output.Password{Length: length, Symbols: symbols, Digits: digits}
+	if revealValues(cmd) {
+		result.Value = &password
+	}
+	if err := utils.CopyToClipboard(password); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: clipboard error:", err)
+	} else {
+		result.Copied = true
+	}
+	return writeOutput(format, result)
+}
+
 func init() {
 	rootCmd.AddCommand(generateCmd)
 
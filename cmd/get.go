@@ -5,7 +5,9 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/kirinyoku/vlxck/internal/output"
 	"github.com/kirinyoku/vlxck/internal/store"
 	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/spf13/cobra"
@@ -16,98 +18,164 @@ var getCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Retrieve a secret from the store",
 	Long: `Retrieve a secret from the store and copy it to the clipboard.
+Use --field to copy the username, URL, notes or a custom field instead of the value.
 
-In interactive mode, you can select the secret from a list.
+In interactive mode, you can select the secret from a list; type to filter it
+with the same fuzzy matching as the search command.
 In non-interactive mode, you must specify the secret name.
+With --output json|yaml|tsv, the secret is printed instead of copied to the clipboard.
 
 Examples:
   # Interactive mode
   vlxck get -i
 
+  # Interactive mode limited to a folder and tag
+  vlxck get -i -c work/aws --tag prod
+
   # Non-interactive mode
-  vlxck get -n example.com`,
+  vlxck get -n example.com
 
-	Run: func(cmd *cobra.Command, args []string) {
-		filePath := getStorePath()
-		password, err := getPassword(false)
+  # Copy the username instead of the value and show the secret's metadata
+  vlxck get -n example.com -f username -d
+
+  # Print the secret as JSON, including its value
+  vlxck get -n example.com --output json --reveal`,
+
+	// Errors go to stderr with a non-zero exit code, as for list
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
 		if err != nil {
-			fmt.Println("Error:", err)
-			return
-		}
-		s, err := store.LoadStore(filePath, password)
-		if err == nil {
-			// Only cache the password if it was successfully used
-			cacheVerifiedPassword(password)
+			return err
 		}
+
+		filePath := getStorePath()
+		s, _, err := unlockStore(filePath)
 		if err != nil {
-			fmt.Println("Error loading store:", err)
-			return
+			return fmt.Errorf("failed to load store: %w", err)
 		}
 
+		field, _ := cmd.Flags().GetString("field")
+		details, _ := cmd.Flags().GetBool("details")
+
 		// Check for interactive mode
 		interactive, _ := cmd.Flags().GetBool("interactive")
 		if interactive {
-			getInteractive(s)
-			return
+			filter, err := secretFilterFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			return getInteractive(filter.Apply(s.Secrets), field, details)
+		}
+
+		// Machine-readable output prints the secret instead of copying it
+		if format.IsStructured() {
+			return getStructured(cmd, s, format)
 		}
 
 		// Non-interactive mode
-		getNonInteractive(cmd, s)
+		return getNonInteractive(cmd, s, field, details)
 	},
 }
 
-// getInteractive handles the interactive get flow
-func getInteractive(s *store.Store) {
-	if len(s.Secrets) == 0 {
+// getInteractive handles the interactive get flow over the given secrets
+func getInteractive(secrets []store.Secret, field string, details bool) error {
+	if len(secrets) == 0 {
 		fmt.Println("No secrets found.")
-		return
-	}
-
-	// Create a list of secret names for selection
-	secretNames := make([]string, 0, len(s.Secrets))
-	for _, secret := range s.Secrets {
-		secretNames = append(secretNames, secret.Name)
+		return nil
 	}
 
-	// Prompt user to select a secret
-	selectedName, err := utils.PromptForSelect("Select secret to retrieve", secretNames)
+	// Prompt user to select a secret; typing filters the list
+	secret, err := utils.PromptForSecret("Select secret to retrieve", secrets)
 	if err != nil {
-		fmt.Println("Error selecting secret:", err)
-		return
+		return fmt.Errorf("failed to select secret: %w", err)
 	}
 
-	// Find and copy the selected secret
-	for _, secret := range s.Secrets {
-		if secret.Name == selectedName {
-			copySecretToClipboard(secret)
-			return
-		}
-	}
+	return retrieveSecret(secret, field, details)
 }
 
 // getNonInteractive handles the non-interactive get flow
-func getNonInteractive(cmd *cobra.Command, s *store.Store) {
+func getNonInteractive(cmd *cobra.Command, s *store.Store, field string, details bool) error {
 	name, _ := cmd.Flags().GetString("name")
 	if name == "" {
-		fmt.Println("Error: secret name is required in non-interactive mode")
-		return
+		return fmt.Errorf("secret name is required in non-interactive mode")
 	}
 
 	for _, secret := range s.Secrets {
 		if secret.Name == name {
-			copySecretToClipboard(secret)
-			return
+			return retrieveSecret(secret, field, details)
 		}
 	}
-	fmt.Printf("Secret '%s' not found.\n", name)
+	return fmt.Errorf("secret '%s' not found", name)
 }
 
-// copySecretToClipboard copies the secret value to clipboard and provides feedback
-func copySecretToClipboard(secret store.Secret) {
-	if err := utils.CopyToClipboard(secret.Value); err != nil {
-		fmt.Printf("Value: %s (clipboard error: %v)\n", secret.Value, err)
-	} else {
+// getStructured prints the named secret in a machine-readable format.
+// The value and hidden custom fields are only included with --reveal.
+func getStructured(cmd *cobra.Command, s *store.Store, format output.Format) error {
+	name, _ := cmd.Flags().GetString("name")
+	for _, secret := range s.Secrets {
+		if secret.Name == name {
+			return writeOutput(format, output.NewSecret(secret, revealValues(cmd)))
+		}
+	}
+	return fmt.Errorf("secret '%s' not found", name)
+}
+
+// retrieveSecret copies the requested field of the secret to the clipboard
+// and optionally prints its metadata. A missing or empty field is an error.
+func retrieveSecret(secret store.Secret, field string, details bool) error {
+	if details {
+		printSecretDetails(secret)
+	}
+
+	value, ok := secret.Lookup(field)
+	if !ok {
+		return fmt.Errorf("field '%s' not found in secret '%s'", field, secret.Name)
+	}
+	if value == "" {
+		return fmt.Errorf("field '%s' of secret '%s' is empty", field, secret.Name)
+	}
+
+	if err := utils.CopyToClipboard(value); err != nil {
+		fmt.Printf("Value: %s (clipboard error: %v)\n", value, err)
+	} else if field == "value" {
 		fmt.Printf("Secret '%s' copied to clipboard.\n", secret.Name)
+	} else {
+		fmt.Printf("Field '%s' of secret '%s' copied to clipboard.\n", field, secret.Name)
+	}
+	return nil
+}
+
+// printSecretDetails prints the metadata of a secret without revealing its value
+// or hidden custom fields
+func printSecretDetails(secret store.Secret) {
+	fmt.Printf("Name:     %s\n", secret.Name)
+	if secret.Category != "" {
+		fmt.Printf("Category: %s\n", secret.Category)
+	}
+	if len(secret.Tags) > 0 {
+		fmt.Printf("Tags:     %s\n", strings.Join(secret.Tags, ", "))
+	}
+	if secret.Username != "" {
+		fmt.Printf("Username: %s\n", secret.Username)
+	}
+	for _, url := range secret.URLs {
+		fmt.Printf("URL:      %s\n", url)
+	}
+	if secret.Notes != "" {
+		fmt.Printf("Notes:    %s\n", secret.Notes)
+	}
+	if secret.IsTOTP() {
+		fmt.Printf("TOTP:     %s, %d digits, %ds period (use 'vlxck otp')\n", secret.TOTP.Algorithm, secret.TOTP.Digits, secret.TOTP.Period)
+	}
+	for _, f := range secret.Fields {
+		fmt.Printf("%s (%s): %s\n", f.Name, f.Type, f.DisplayValue())
+	}
+	if !secret.CreatedAt.IsZero() {
+		fmt.Printf("Created:  %s\n", secret.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	if !secret.UpdatedAt.IsZero() {
+		fmt.Printf("Updated:  %s\n", secret.UpdatedAt.Format("2006-01-02 15:04:05"))
 	}
 }
 
@@ -117,6 +185,10 @@ func init() {
 	// Define command flags with shorthand and descriptions
 	getCmd.Flags().StringP("name", "n", "", "Name of the secret (required in non-interactive mode)")
 	getCmd.Flags().BoolP("interactive", "i", false, "Use interactive mode to select from a list")
+	getCmd.Flags().StringP("field", "f", "value", "Field to copy: value, username, url, notes or a custom field name")
+	getCmd.Flags().BoolP("details", "d", false, "Print username, URLs, notes and custom fields (hidden fields are masked)")
+	getCmd.Flags().StringP("category", "c", "", "Interactive mode: only list secrets in this category folder")
+	getCmd.Flags().StringSlice("tag", nil, "Interactive mode: only list secrets with this tag, prefix with ! to exclude (repeatable or comma-separated)")
 
 	// Mark name as required only in non-interactive mode
 	getCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
@@ -125,6 +197,9 @@ func init() {
 		if !interactive && name == "" {
 			return fmt.Errorf("either --name or --interactive flag is required")
 		}
+		if format, _ := outputFormat(cmd); interactive && format.IsStructured() {
+			return fmt.Errorf("--interactive cannot be combined with --output %s", format)
+		}
 		return nil
 	}
 }
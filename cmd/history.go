@@ -0,0 +1,73 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'history' command which is used to
+// show the previous values of a secret.
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the 'history' command that lists the previous values of a secret.
+// Values are masked unless --reveal is given.
+//
+// The command supports the following flags:
+//   - name (-n): The name of the secret (required)
+//   - reveal (-r): Show the previous values in plain text
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the previous values of a secret",
+	Long: `Show the previous values of a secret, most recent first.
+Values are masked unless --reveal is given. Use 'vlxck rollback' to restore one.
+
+Examples:
+  # Show masked history
+  vlxck history -n example.com
+
+  # Show history with values
+  vlxck history -n example.com -r`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		reveal, _ := cmd.Flags().GetBool("reveal")
+
+		filePath := getStorePath()
+		s, _, err := unlockStore(filePath)
+		if err != nil {
+			fmt.Println("Error loading store:", err)
+			return
+		}
+
+		for _, secret := range s.Secrets {
+			if secret.Name != name {
+				continue
+			}
+			if len(secret.History) == 0 {
+				fmt.Printf("No history for secret '%s'.\n", name)
+				return
+			}
+			fmt.Printf("History for secret '%s':\n", name)
+			for i, entry := range secret.History {
+				value := store.MaskedValue
+				if reveal {
+					value = entry.Value
+				}
+				fmt.Printf("%3d. %s  %s\n", i+1, entry.ChangedAt.Format("2006-01-02 15:04:05"), value)
+			}
+			return
+		}
+		fmt.Printf("Secret '%s' not found.\n", name)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	// Define command flags with shorthand and descriptions
+	historyCmd.Flags().StringP("name", "n", "", "Name of the secret (required)")
+	historyCmd.Flags().BoolP("reveal", "r", false, "Show previous values in plain text")
+
+	// Mark required flags
+	historyCmd.MarkFlagRequired("name")
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/kirinyoku/vlxck/internal/csvio"
 	"github.com/kirinyoku/vlxck/internal/store"
 	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/spf13/cobra"
@@ -22,18 +23,56 @@ import (
 //   - file (-f): The path to the import file (required)
 //   - use-store-password (-p): Whether to use the store's master password for import
 //   - merge (-m): Whether to merge secrets from import file into existing store
+//   - format: store (default) or csv; CSV files are always merged
+//   - map: Maps fields to CSV columns, as field=column pairs
+//   - unescape: Remove the formula escapes of CSV files exported by vlxck
 var importCmd = &cobra.Command{
 	Use:   "import",
 	Short: "Import secrets by replacing or merging with an encrypted file",
+	Long: `Import secrets by replacing or merging with an encrypted store file.
+
+With --format csv, the secrets of a CSV file are merged into the store. A header
+row is detected by its column names (e.g. name or title, value or password,
+category or folder); without one, the columns are taken in the order name,
+value, category, tags, username, urls, notes. --map maps fields to other
+column names or to 1-based column numbers. Tags and URLs are comma-separated.
+Cells are imported as they are; for a file written by 'vlxck export --format csv',
+--unescape removes the ' it puts before cells that spreadsheets would take for
+formulas.
+
+Examples:
+  # Merge an encrypted store file into the store
+  vlxck import -f ~/exports/store.dat -m
+
+  # Import a CSV file exported from another password manager
+  vlxck import -f passwords.csv --format csv --map name=title,category=group`,
 	Run: func(cmd *cobra.Command, args []string) {
 		filePath := getStorePath()
 		importPath, _ := cmd.Flags().GetString("file")
 		useStorePassword, _ := cmd.Flags().GetBool("use-store-password")
 		merge, _ := cmd.Flags().GetBool("merge")
+
+		format, err := transferFormat(cmd)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if format == formatCSV {
+			mapSpec, _ := cmd.Flags().GetString("map")
+			mapping, err := csvio.ParseMapping(mapSpec)
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			unescape, _ := cmd.Flags().GetBool("unescape")
+			importCSV(filePath, importPath, mapping, unescape)
+			return
+		}
+
 		var importPassword string
 
 		if useStorePassword {
-			password, err := getPassword(false)
+			password, err := getPassword()
 			if err != nil {
 				fmt.Println("Error:", err)
 				return
@@ -52,7 +91,7 @@ var importCmd = &cobra.Command{
 		if merge {
 			storePassword := importPassword
 			if !useStorePassword {
-				password, err := getPassword(false)
+				password, err := getPassword()
 				if err != nil {
 					fmt.Println("Error:", err)
 					return
@@ -118,13 +157,18 @@ var importCmd = &cobra.Command{
 			}
 
 			currentStore.Secrets = mergedSecrets
+			snapshotStore(filePath, "import")
 			if err := store.SaveStore(filePath, importPassword, currentStore); err != nil {
 				fmt.Println("Error saving store:", err)
 				return
 			}
-			// Cache the password if it was successfully used
-			cacheVerifiedPassword(importPassword)
+			// The store is now encrypted with the import password
+			forgetKey(filePath)
+			if key, err := store.DeriveKey(filePath, importPassword); err == nil {
+				rememberKey(filePath, key)
+			}
 
+			recordChange(filePath, "import")
 			fmt.Printf("Successfully merged %d secrets (%d overwritten, %d skipped) from %s\n", importedCount, overwrittenCount, skippedCount, importPath)
 		} else {
 			if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
@@ -139,6 +183,7 @@ var importCmd = &cobra.Command{
 			}
 			defer sourceFile.Close()
 
+			snapshotStore(filePath, "import")
 			targetFile, err := os.Create(filePath)
 			if err != nil {
 				fmt.Println("Error creating store file:", err)
@@ -156,6 +201,8 @@ var importCmd = &cobra.Command{
 				return
 			}
 
+			forgetKey(filePath)
+			recordChange(filePath, "import")
 			fmt.Printf("Store successfully replaced with %s\n", importPath)
 		}
 	},
@@ -168,6 +215,9 @@ func init() {
 	importCmd.Flags().StringP("file", "f", "", "Path to import file (required)")
 	importCmd.Flags().BoolP("use-store-password", "p", false, "Use the store's master password for import")
 	importCmd.Flags().BoolP("merge", "m", false, "Merge secrets from import file into existing store")
+	importCmd.Flags().String("format", formatStore, "Import format: store (encrypted) or csv (plaintext, always merged)")
+	importCmd.Flags().String("map", "", "CSV columns as field=column pairs, by header name or number, e.g. name=title,value=password")
+	importCmd.Flags().Bool("unescape", false, "Remove the ' that 'vlxck export --format csv' puts before formula-like cells (CSV only)")
 
 	// Mark required flags
 	importCmd.MarkFlagRequired("file")
@@ -0,0 +1,105 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'inject' command which is used to
+// render configuration templates with values from the store.
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kirinyoku/vlxck/internal/inject"
+	"github.com/kirinyoku/vlxck/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// injectCmd represents the 'inject' command that renders a template, replacing
+// secret references with values from the store. Output files are written with
+// 0600 permissions and atomically, so a failed render never leaves a partial file.
+//
+// The command supports the following flags:
+//   - input (-i): The template file ("-" for standard input)
+//   - output (-o): The file to write (standard output if omitted)
+var injectCmd = &cobra.Command{
+	Use:   "inject -i TEMPLATE [-o OUTPUT]",
+	Short: "Render a template with secrets from the store",
+	Long: `Render a configuration template, replacing secret references with values
+from the store. Templates can be kept in version control and rendered locally.
+
+References use Go template syntax, where the secret is a name or folder/name
+and the field defaults to value:
+  {{ vlxck "prod/db" }}
+  {{ vlxck "prod/db" "username" }}
+
+Bare references are replaced anywhere outside {{ }}:
+  vlxck://prod/db#password
+
+Nothing is written unless every reference resolves. Output files are created
+with 0600 permissions and replaced atomically.
+
+Examples:
+  # Render a config file
+  vlxck inject -i config.tmpl -o config.yaml
+
+  # Render to standard output
+  vlxck inject -i config.tmpl`,
+	Run: func(cmd *cobra.Command, args []string) {
+		inputPath, _ := cmd.Flags().GetString("input")
+		outputPath, _ := cmd.Flags().GetString("output")
+
+		text, err := readTemplate(inputPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		filePath := getStorePath()
+		s, _, err := unlockStore(filePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading store:", err)
+			os.Exit(1)
+		}
+
+		rendered, err := inject.Render(inputPath, text, s.Resolve)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error rendering template:", err)
+			os.Exit(1)
+		}
+
+		if outputPath == "" || outputPath == "-" {
+			os.Stdout.Write(rendered)
+			return
+		}
+		if err := utils.WriteFileAtomic(outputPath, rendered, 0600); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing output:", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Rendered %s to %s.\n", inputPath, outputPath)
+	},
+}
+
+// readTemplate reads the template from a file, or from standard input if path is "-".
+func readTemplate(path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read template from stdin: %v", err)
+		}
+		return string(data), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %v", err)
+	}
+	return string(data), nil
+}
+
+func init() {
+	rootCmd.AddCommand(injectCmd)
+
+	// Define command flags with shorthand and descriptions
+	injectCmd.Flags().StringP("input", "i", "", "Template file to render (- for standard input)")
+	injectCmd.Flags().StringP("output", "o", "", "File to write (standard output if omitted)")
+
+	injectCmd.MarkFlagRequired("input")
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 
 	"github.com/kirinyoku/vlxck/internal/backup"
+	"github.com/kirinyoku/vlxck/internal/output"
 	"github.com/spf13/cobra"
 )
 
@@ -13,8 +14,15 @@ import (
 var listBackupsCmd = &cobra.Command{
 	Use:   "list-backups [backup-dir]",
 	Short: "List all available backups",
-	Long:  `List all available backups in the specified directory or the default backup location.`,
-	Args:  cobra.MaximumNArgs(1),
+	Long: `List all available backups in the specified directory or the default backup location.
+
+Examples:
+  # List backups in the default location
+  vlxck list-backups
+
+  # Print backups as JSON for scripts
+  vlxck list-backups --output json`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		backupDir := filepath.Join(filepath.Dir(getStorePath()), "backups")
 
@@ -22,11 +30,20 @@ var listBackupsCmd = &cobra.Command{
 			backupDir = args[0]
 		}
 
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
 		backups, err := backup.ListBackups(backupDir)
 		if err != nil {
 			return fmt.Errorf("failed to list backups: %w", err)
 		}
 
+		if format.IsStructured() {
+			return writeOutput(format, output.NewBackups(backups))
+		}
+
 		if len(backups) == 0 {
 			fmt.Printf("No backups found in: %s\n", backupDir)
 			return nil
@@ -36,11 +53,16 @@ var listBackupsCmd = &cobra.Command{
 		for i, b := range backups {
 			sizeKB := float64(b.Size) / 1024.0
 			modTime := b.ModTime.Format("2006-01-02 15:04:05")
-			fmt.Printf("%d. %-35s  %8.1f KB  %s\n",
+			note := ""
+			if !b.Encrypted {
+				note = "  (plaintext)"
+			}
+			fmt.Printf("%d. %-35s  %8.1f KB  %s%s\n",
 				i+1,
 				b.Name,
 				sizeKB,
-				modTime)
+				modTime,
+				note)
 		}
 		return nil
 	},
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"text/tabwriter"
 
+	"github.com/kirinyoku/vlxck/internal/output"
 	"github.com/kirinyoku/vlxck/internal/store"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +19,7 @@ const (
 	SecretsPerPage = 10           // Number of secrets to display per page
 	MaxNameLen     = 20           // Maximum length for Name column
 	MaxCategoryLen = 20           // Maximum length for Category column
+	MaxUsernameLen = 20           // Maximum length for Username column
 	IndexWidth     = 5            // Width for Index column (e.g., "1")
 	HeaderColor    = "\033[1;34m" // ANSI color for headers (blue)
 	ResetColor     = "\033[0m"    // Reset ANSI color
@@ -35,9 +37,11 @@ func truncateString(s string, maxLen int) string {
 // It prompts the user for the master password and displays the list of secrets in a formatted table.
 // If the store is not found, it displays an error message.
 // The list is paginated, showing SecretsPerPage secrets at a time, with user input to navigate pages.
+// With --output json|yaml|tsv, all matching secrets are printed at once without pagination.
 //
 // The command supports the following flags:
-//   - category (-c): Optional category for filtering secrets
+//   - category (-c): Optional category folder for filtering secrets (includes subfolders)
+//   - tag: Optional tag expression for filtering secrets (repeatable, prefix with ! to exclude)
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all secrets",
@@ -47,79 +51,92 @@ Examples:
   # List all secrets
   vlxck list
 
-  # List secrets by category
-  vlxck list -c games`,
-	Run: func(cmd *cobra.Command, args []string) {
-		filePath := getStorePath()
+  # List secrets by category folder (includes subfolders such as games/steam)
+  vlxck list -c games
+
+  # List production secrets that are not tagged legacy
+  vlxck list -c work --tag prod --tag '!legacy'
 
-		password, err := getPassword(false)
+  # Print all secrets as JSON for scripts (values hidden unless --reveal)
+  vlxck list --output json`,
+	// Errors go to stderr with a non-zero exit code, so that scripts reading
+	// structured output never parse an error message
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
 		if err != nil {
-			fmt.Println("Error:", err)
-			return
+			return err
 		}
 
-		s, err := store.LoadStore(filePath, password)
-		if err == nil {
-			cacheVerifiedPassword(password)
+		filePath := getStorePath()
+
+		s, _, err := unlockStore(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to load store: %w", err)
 		}
+
+		filter, err := secretFilterFromFlags(cmd)
 		if err != nil {
-			fmt.Println("Error loading store:", err)
-			return
+			return err
 		}
 
-		category, _ := cmd.Flags().GetString("category")
+		filteredSecrets := filter.Apply(s.Secrets)
 
-		var filteredSecrets []store.Secret
-		for _, secret := range s.Secrets {
-			if category == "" || secret.Category == category {
-				filteredSecrets = append(filteredSecrets, secret)
-			}
+		// Machine-readable output lists everything at once, without pagination
+		if format.IsStructured() {
+			return writeOutput(format, output.NewSecrets(filteredSecrets, revealValues(cmd)))
 		}
 
 		if len(filteredSecrets) == 0 {
 			fmt.Println("No secrets found" + func() string {
-				if category != "" {
-					return " for category " + category
+				if !filter.IsEmpty() {
+					return " for " + filter.String()
 				}
 				return ""
 			}() + ".")
-			return
+			return nil
 		}
 
-		// Initialize pagination
-		currentPage := 0
-		totalPages := (len(filteredSecrets) + SecretsPerPage - 1) / SecretsPerPage
-		scanner := bufio.NewScanner(os.Stdin)
-
-		for {
-			// Display current page
-			displaySecretsPage(filteredSecrets, currentPage, totalPages)
-
-			// Prompt for navigation if multiple pages
-			if totalPages > 1 {
-				fmt.Printf("\nPage %d of %d. Enter (n)ext, (p)revious, or (q)uit: ", currentPage+1, totalPages)
-				if scanner.Scan() {
-					input := strings.ToLower(strings.TrimSpace(scanner.Text()))
-					switch input {
-					case "n":
-						if currentPage < totalPages-1 {
-							currentPage++
-						}
-					case "p":
-						if currentPage > 0 {
-							currentPage--
-						}
-					case "q":
-						return
-					default:
-						fmt.Println("Invalid input. Use 'n' for next, 'p' for previous, or 'q' to quit.")
+		paginateSecrets(filteredSecrets)
+		return nil
+	},
+}
+
+// paginateSecrets displays the secrets as a table, SecretsPerPage at a time,
+// prompting for navigation when there is more than one page.
+func paginateSecrets(secrets []store.Secret) {
+	currentPage := 0
+	totalPages := (len(secrets) + SecretsPerPage - 1) / SecretsPerPage
+	scanner := bufio.NewScanner(os.Stdin)
+
+	for {
+		// Display current page
+		displaySecretsPage(secrets, currentPage, totalPages)
+
+		// Prompt for navigation if multiple pages
+		if totalPages > 1 {
+			fmt.Printf("\nPage %d of %d. Enter (n)ext, (p)revious, or (q)uit: ", currentPage+1, totalPages)
+			if scanner.Scan() {
+				input := strings.ToLower(strings.TrimSpace(scanner.Text()))
+				switch input {
+				case "n":
+					if currentPage < totalPages-1 {
+						currentPage++
 					}
+				case "p":
+					if currentPage > 0 {
+						currentPage--
+					}
+				case "q":
+					return
+				default:
+					fmt.Println("Invalid input. Use 'n' for next, 'p' for previous, or 'q' to quit.")
 				}
-			} else {
-				break
 			}
+		} else {
+			break
 		}
-	},
+	}
 }
 
 // displaySecretsPage displays a single page of secrets in a formatted table.
@@ -131,25 +148,28 @@ func displaySecretsPage(secrets []store.Secret, currentPage, totalPages int) {
 	headerIndex := "Index"
 	headerName := "Name"
 	headerCategory := "Category"
+	headerUsername := "Username"
 
 	// Calculate border lengths based on maximum content width
 	borderIndex := strings.Repeat("─", IndexWidth)
 	borderName := strings.Repeat("─", MaxNameLen)
 	borderCategory := strings.Repeat("─", MaxCategoryLen)
+	borderUsername := strings.Repeat("─", MaxUsernameLen)
 
 	// Print top border
-	fmt.Fprintf(w, "%s┌─%s─┬─%s─┬─%s─┐%s\n", BorderColor, borderIndex, borderName, borderCategory, ResetColor)
+	fmt.Fprintf(w, "%s┌─%s─┬─%s─┬─%s─┬─%s─┐%s\n", BorderColor, borderIndex, borderName, borderCategory, borderUsername, ResetColor)
 
 	// Print header row
-	fmt.Fprintf(w, "%s│ %s%-*s%s │ %s%-*s%s │ %s%-*s%s │%s\n",
+	fmt.Fprintf(w, "%s│ %s%-*s%s │ %s%-*s%s │ %s%-*s%s │ %s%-*s%s │%s\n",
 		BorderColor,
 		HeaderColor, IndexWidth, headerIndex, ResetColor,
 		HeaderColor, MaxNameLen, headerName, ResetColor,
 		HeaderColor, MaxCategoryLen, headerCategory, ResetColor,
+		HeaderColor, MaxUsernameLen, headerUsername, ResetColor,
 		ResetColor)
 
 	// Print header separator
-	fmt.Fprintf(w, "%s├─%s─┼─%s─┼─%s─┤%s\n", BorderColor, borderIndex, borderName, borderCategory, ResetColor)
+	fmt.Fprintf(w, "%s├─%s─┼─%s─┼─%s─┼─%s─┤%s\n", BorderColor, borderIndex, borderName, borderCategory, borderUsername, ResetColor)
 
 	// Calculate page range
 	start := currentPage * SecretsPerPage
@@ -162,11 +182,12 @@ func displaySecretsPage(secrets []store.Secret, currentPage, totalPages int) {
 	for i, secret := range secrets[start:end] {
 		name := truncateString(secret.Name, MaxNameLen)
 		category := truncateString(secret.Category, MaxCategoryLen)
-		fmt.Fprintf(w, "│ %-*d │ %-*s │ %-*s │\n", IndexWidth, start+i+1, MaxNameLen, name, MaxCategoryLen, category)
+		username := truncateString(secret.Username, MaxUsernameLen)
+		fmt.Fprintf(w, "│ %-*d │ %-*s │ %-*s │ %-*s │\n", IndexWidth, start+i+1, MaxNameLen, name, MaxCategoryLen, category, MaxUsernameLen, username)
 	}
 
 	// Print bottom border
-	fmt.Fprintf(w, "%s└─%s─┴─%s─┴─%s─┘%s\n", BorderColor, borderIndex, borderName, borderCategory, ResetColor)
+	fmt.Fprintf(w, "%s└─%s─┴─%s─┴─%s─┴─%s─┘%s\n", BorderColor, borderIndex, borderName, borderCategory, borderUsername, ResetColor)
 
 	// Flush output to ensure proper rendering
 	w.Flush()
@@ -176,5 +197,13 @@ func init() {
 	rootCmd.AddCommand(listCmd)
 
 	// Define command flags with shorthand and descriptions
-	listCmd.Flags().StringP("category", "c", "", "Filter by category")
+	listCmd.Flags().StringP("category", "c", "", "Filter by category folder (includes subfolders)")
+	listCmd.Flags().StringSlice("tag", nil, "Filter by tag, prefix with ! to exclude (repeatable or comma-separated)")
+}
+
+// secretFilterFromFlags builds a secret filter from the --category and --tag flags.
+func secretFilterFromFlags(cmd *cobra.Command) (store.Filter, error) {
+	category, _ := cmd.Flags().GetString("category")
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	return store.ParseFilter(category, tags)
 }
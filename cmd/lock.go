@@ -0,0 +1,46 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'lock' command which is used to
+// forget all unlocked and cached keys.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kirinyoku/vlxck/internal/agent"
+	"github.com/kirinyoku/vlxck/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+// lockCmd represents the 'lock' command that makes the unlock agent drop all
+// keys and clears the key cache, so the next command prompts for the
+// master password again.
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the store by forgetting unlocked and cached keys",
+	Long: `Lock the store immediately: the unlock agent drops all keys it holds and the
+key cache is wiped. The next command will prompt for the master password.
+
+Examples:
+  vlxck lock`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cache.ClearAll(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to clear key cache: %v\n", err)
+		}
+
+		client := agent.NewClient(agent.SocketPath())
+		if !client.Running() {
+			fmt.Println("Store locked (agent is not running).")
+			return
+		}
+		if err := client.Lock(); err != nil {
+			fmt.Println("Error locking agent:", err)
+			return
+		}
+		fmt.Println("Store locked.")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+}
@@ -0,0 +1,118 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'otp' command which is used to
+// generate time-based one-time passwords from stored TOTP seeds.
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/totp"
+	"github.com/kirinyoku/vlxck/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// otpCmd represents the 'otp' command that computes the current TOTP code
+// for a secret, copies it to the clipboard and shows how long it remains valid.
+//
+// The command supports the following flags:
+//   - name (-n): The name of the TOTP secret (required)
+var otpCmd = &cobra.Command{
+	Use:   "otp",
+	Short: "Generate the current TOTP code for a secret",
+	Long: `Generate the current time-based one-time password (RFC 6238) for a
+TOTP secret and copy it to the clipboard.
+
+TOTP secrets are created with 'vlxck add --totp' or by adding an otpauth:// URI.
+
+Examples:
+  vlxck otp -n github-2fa`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+
+		filePath := getStorePath()
+		s, _, err := unlockStore(filePath)
+		if err != nil {
+			fmt.Println("Error loading store:", err)
+			return
+		}
+
+		for _, secret := range s.Secrets {
+			if secret.Name != name {
+				continue
+			}
+			if !secret.IsTOTP() {
+				fmt.Printf("Error: secret '%s' is not a TOTP secret\n", name)
+				return
+			}
+
+			now := time.Now()
+			code, err := totp.Generate(secret.Value, *secret.TOTP, now)
+			if err != nil {
+				fmt.Println("Error generating code:", err)
+				return
+			}
+			remaining := totp.Remaining(*secret.TOTP, now)
+
+			if err := utils.CopyToClipboard(code); err != nil {
+				fmt.Printf("Code: %s (clipboard error: %v)\n", code, err)
+			} else {
+				fmt.Printf("Code for '%s' copied to clipboard.\n", name)
+			}
+			fmt.Printf("Valid for %d more seconds.\n", int(remaining.Seconds()))
+			return
+		}
+		fmt.Printf("Secret '%s' not found.\n", name)
+	},
+}
+
+// totpParamsFromFlags builds TOTP parameters from the --totp-* flags.
+func totpParamsFromFlags(cmd *cobra.Command) (totp.Params, error) {
+	algorithm, _ := cmd.Flags().GetString("totp-algorithm")
+	digits, _ := cmd.Flags().GetInt("totp-digits")
+	period, _ := cmd.Flags().GetInt("totp-period")
+
+	params := totp.Params{
+		Algorithm: totp.Algorithm(strings.ToUpper(algorithm)),
+		Digits:    digits,
+		Period:    period,
+	}
+	return params, params.Validate()
+}
+
+// setupTOTP turns a secret into a TOTP secret. If the value is an otpauth://
+// URI, the seed and parameters are taken from it and the given params are ignored;
+// otherwise the value is validated as a base32 seed.
+func setupTOTP(secret *store.Secret, params totp.Params) error {
+	if strings.HasPrefix(secret.Value, "otpauth://") {
+		seed, uriParams, err := totp.ParseURI(secret.Value)
+		if err != nil {
+			return err
+		}
+		secret.Value = seed
+		params = uriParams
+		if secret.Username == "" {
+			secret.Username = params.Account
+		}
+	} else {
+		seed, err := totp.NormalizeSeed(secret.Value)
+		if err != nil {
+			return err
+		}
+		secret.Value = seed
+	}
+	secret.TOTP = &params
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(otpCmd)
+
+	// Define command flags with shorthand and descriptions
+	otpCmd.Flags().StringP("name", "n", "", "Name of the TOTP secret (required)")
+
+	// Mark required flags
+	otpCmd.MarkFlagRequired("name")
+}
@@ -0,0 +1,133 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'rekey' command which is used to
+// re-tune the Argon2id cost parameters and rotate the salt of the encrypted store.
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/crypto"
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// rekeyCmd represents the 'rekey' command that re-encrypts the store with
+// freshly calibrated Argon2id parameters and a new random salt.
+// It benchmarks the machine, proposes parameters targeting the chosen unlock time
+// and asks for confirmation before rewriting the store.
+//
+// The command supports the following flags:
+//   - target: Desired unlock time (default: 500ms)
+//   - memory (-m): Memory to use for key derivation in MiB (default: 64)
+//   - threads (-p): Degree of parallelism (default: number of CPUs, at most 4)
+//   - yes (-y): Skip the confirmation prompt
+var rekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Re-tune the key derivation cost and rotate the store salt",
+	Long: `Benchmark this machine, propose Argon2id parameters targeting the chosen
+unlock time, generate a fresh salt and re-encrypt the store.
+
+The master password stays the same. Use this periodically to harden older
+stores as hardware gets faster.
+
+Examples:
+  # Target a 500ms unlock time (default)
+  vlxck rekey
+
+  # Target one second using 256 MiB of memory
+  vlxck rekey --target 1s --memory 256`,
+	Run: func(cmd *cobra.Command, args []string) {
+		target, _ := cmd.Flags().GetDuration("target")
+		memoryMiB, _ := cmd.Flags().GetUint32("memory")
+		threads, _ := cmd.Flags().GetUint8("threads")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		if target <= 0 {
+			fmt.Println("Error: target must be positive")
+			return
+		}
+		if memoryMiB == 0 || memoryMiB > crypto.MaxKDFMemory/1024 {
+			fmt.Printf("Error: memory must be between 1 and %d MiB\n", crypto.MaxKDFMemory/1024)
+			return
+		}
+		if threads == 0 {
+			fmt.Println("Error: threads must be positive")
+			return
+		}
+
+		filePath := getStorePath()
+		password, err := getPassword()
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		s, err := store.LoadStore(filePath, password)
+		if err != nil {
+			fmt.Println("Error loading store:", err)
+			return
+		}
+
+		current, err := store.ReadHeader(filePath)
+		if err != nil {
+			fmt.Println("Error reading store header:", err)
+			return
+		}
+		fmt.Printf("Current parameters:  %s\n", formatKDFParams(current.Params))
+
+		fmt.Printf("Benchmarking Argon2id for a %s unlock time...\n", target)
+		params, elapsed := crypto.CalibrateKDFParams(target, memoryMiB*1024, threads)
+		fmt.Printf("Proposed parameters: %s (measured %s)\n", formatKDFParams(params), elapsed.Round(time.Millisecond))
+
+		if !yes {
+			confirm, err := utils.PromptForConfirm("Re-encrypt the store with these parameters and a new salt")
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			if !confirm {
+				fmt.Println("Rekey cancelled.")
+				return
+			}
+		}
+
+		header, err := store.NewHeader(params)
+		if err != nil {
+			fmt.Println("Error generating salt:", err)
+			return
+		}
+		snapshotStore(filePath, "rekey")
+		if err := store.SaveStoreWithHeader(filePath, password, s, header); err != nil {
+			fmt.Println("Error saving store:", err)
+			return
+		}
+
+		// Keys derived with the old salt no longer match
+		forgetKey(filePath)
+
+		recordChange(filePath, "rekey")
+		fmt.Println("Store re-encrypted successfully.")
+	},
+}
+
+// formatKDFParams renders Argon2id parameters in a human-readable form.
+func formatKDFParams(params crypto.KDFParams) string {
+	return fmt.Sprintf("time=%d, memory=%d MiB, threads=%d", params.Time, params.Memory/1024, params.Threads)
+}
+
+func init() {
+	rootCmd.AddCommand(rekeyCmd)
+
+	defaultThreads := runtime.NumCPU()
+	if defaultThreads > 4 {
+		defaultThreads = 4
+	}
+
+	// Define command flags with shorthand and descriptions
+	rekeyCmd.Flags().Duration("target", 500*time.Millisecond, "Desired unlock time")
+	rekeyCmd.Flags().Uint32P("memory", "m", crypto.DefaultKDFParams.Memory/1024, "Memory to use for key derivation in MiB")
+	rekeyCmd.Flags().Uint8P("threads", "p", uint8(defaultThreads), "Degree of parallelism for key derivation")
+	rekeyCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+}
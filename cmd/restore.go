@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/kirinyoku/vlxck/internal/backup"
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/store"
 	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
@@ -19,47 +22,63 @@ var restoreCmd = &cobra.Command{
 	Short: "Restore a backup of the password store",
 	Long: `Restore a previously created backup to the specified directory.
 If no backup file is provided, you'll be prompted to select one interactively.
-If no target directory is provided, restores to the default store location.`,
+If no target directory is provided, restores to the default store location.
+
+Only the store is restored. The backed-up config.yaml holds the sync settings
+and credentials of the time of the backup; it is restored too with --config.
+If the restored store has another master password than the sync credentials
+in the current config, you are asked for both to re-encrypt them.
+
+Encrypted backups ask for the master password they were created with, or for
+their backup passphrase. Plaintext backups from older versions are restored as they are.
+
+Every file is checked against the SHA-256 checksums recorded in the backup
+before anything is written. With --dry-run, nothing is written: the secrets
+that restoring would add, change or remove are listed instead.
+
+Single secrets can be restored into the current store with --secret or
+--pick, leaving everything else as it is. They are taken from the backup given
+with --from (or as the first argument, or chosen with -i), by default the
+newest backup.
+
+Examples:
+  # Choose a backup and see what restoring it would change
+  vlxck restore -i --dry-run
+
+  # Bring back a secret deleted by mistake from the newest backup
+  vlxck restore --secret example.com
+
+  # Choose secrets to restore from a specific backup
+  vlxck restore --pick --from ~/.vlxck/backups/backup_20250620-183238.enc
+
+  # Restore a specific backup
+  vlxck restore ~/.vlxck/backups/backup_20250620-183238.enc`,
 	Args: cobra.RangeArgs(0, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		interactive, err := cmd.Flags().GetBool("interactive")
 		if err != nil {
 			return fmt.Errorf("failed to get interactive flag: %w", err)
 		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return fmt.Errorf("failed to get dry-run flag: %w", err)
+		}
+		restoreConfig, err := cmd.Flags().GetBool("config")
+		if err != nil {
+			return fmt.Errorf("failed to get config flag: %w", err)
+		}
+
+		if cmd.Flags().Changed("secret") || cmd.Flags().Changed("pick") {
+			return restoreSecrets(cmd, args, interactive, dryRun)
+		}
 
 		var backupFile string
 
 		if len(args) == 0 || interactive {
-			backupDir := filepath.Join(filepath.Dir(getStorePath()), "backups")
-			backups, err := backup.ListBackups(backupDir)
+			backupFile, err = selectBackup()
 			if err != nil {
-				return fmt.Errorf("failed to list backups: %w", err)
-			}
-
-			if len(backups) == 0 {
-				return fmt.Errorf("no backups found in %s", backupDir)
+				return err
 			}
-
-			var options []string
-			for _, b := range backups {
-				sizeKB := float64(b.Size) / 1024.0
-				option := fmt.Sprintf("%-35s  %8.1f KB  %s",
-					b.Name, sizeKB, b.ModTime.Format("2006-01-02 15:04:05"))
-				options = append(options, option)
-			}
-
-			prompt := promptui.Select{
-				Label: "Select backup to restore",
-				Items: options,
-				Size:  10,
-			}
-
-			selected, _, err := prompt.Run()
-			if err != nil {
-				return fmt.Errorf("backup selection cancelled: %w", err)
-			}
-
-			backupFile = backups[selected].Path
 		} else {
 			backupFile, err = filepath.Abs(args[0])
 			if err != nil {
@@ -87,13 +106,26 @@ If no target directory is provided, restores to the default store location.`,
 			return fmt.Errorf("backup file is empty")
 		}
 
+		fmt.Printf("Backup file: %s (%d bytes)\n", backupFile, fileInfo.Size())
+		fmt.Printf("Restore to: %s\n", targetDir)
+
+		if dryRun {
+			return previewRestore(backupFile, targetDir, storePath, restoreConfig)
+		}
+
+		names := []string{backup.StoreFile}
+		if restoreConfig {
+			names = append(names, backup.ConfigFile)
+		}
+
 		if err := os.MkdirAll(targetDir, 0755); err != nil {
 			return fmt.Errorf("failed to create target directory: %w", err)
 		}
-
-		fmt.Printf("Backup file: %s (%d bytes)\n", backupFile, fileInfo.Size())
-		fmt.Printf("Restore to: %s\n", targetDir)
-		fmt.Println("WARNING: This will overwrite any existing files in the target directory!")
+		if restoreConfig {
+			fmt.Println("WARNING: This will overwrite the store and config.yaml in the target directory!")
+		} else {
+			fmt.Println("WARNING: This will overwrite the store in the target directory!")
+		}
 
 		confirm, err := utils.PromptForConfirm("Are you sure you want to continue? (y/N): ")
 		if err != nil {
@@ -104,18 +136,220 @@ If no target directory is provided, restores to the default store location.`,
 			return fmt.Errorf("restore cancelled")
 		}
 
-		if err := backup.Restore(backupFile, targetDir); err != nil {
+		var before *store.Header
+		if targetDir == storeDir {
+			snapshotStore(storePath, "restore")
+			before, _ = store.ReadHeader(storePath)
+		}
+		if err := backup.Restore(backupFile, targetDir, names, backupKey(storePath)); err != nil {
 			return fmt.Errorf("restore failed: %w", err)
 		}
-
 		fmt.Printf("✓ Backup restored successfully to: %s\n", targetDir)
+
+		if targetDir == storeDir {
+			if restoreConfig {
+				fmt.Println("The sync settings and credentials are those of the backup.")
+			} else {
+				checkSyncCredentials(storePath, before)
+			}
+			recordChange(storePath, "restore")
+		}
 		return nil
 	},
 }
 
+// selectBackup lets the user choose one of the backups in the default backup
+// directory, newest first.
+//
+// Returns:
+//   - string: The path of the selected backup
+//   - error: An error if there are no backups or the selection was cancelled
+func selectBackup() (string, error) {
+	backupDir := filepath.Join(filepath.Dir(getStorePath()), "backups")
+	backups, err := backup.ListBackups(backupDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	if len(backups) == 0 {
+		return "", fmt.Errorf("no backups found in %s", backupDir)
+	}
+
+	var options []string
+	for _, b := range backups {
+		sizeKB := float64(b.Size) / 1024.0
+		option := fmt.Sprintf("%-35s  %8.1f KB  %s",
+			b.Name, sizeKB, b.ModTime.Format("2006-01-02 15:04:05"))
+		options = append(options, option)
+	}
+
+	prompt := promptui.Select{
+		Label: "Select backup to restore",
+		Items: options,
+		Size:  10,
+	}
+
+	selected, _, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("backup selection cancelled: %w", err)
+	}
+
+	return backups[selected].Path, nil
+}
+
+// previewRestore opens and verifies the backup, then lists the secrets that
+// restoring it would add, change or remove in the store of the target
+// directory. Nothing is written.
+//
+// Parameters:
+//   - backupFile: The backup file to preview
+//   - targetDir: The directory the backup would be restored to
+//   - storePath: Path to the store whose cached key to try
+//   - restoreConfig: Whether config.yaml would be restored too
+//
+// Returns:
+//   - error: Any error that occurred while opening the backup or loading either store
+func previewRestore(backupFile, targetDir, storePath string, restoreConfig bool) error {
+	keyFor := backupKey(storePath)
+	archive, err := backup.Open(backupFile, keyFor)
+	if err != nil {
+		return err
+	}
+	if archive.Verified {
+		fmt.Printf("Verified %d files against the backup manifest.\n", len(archive.Files))
+	} else {
+		fmt.Println("Warning: The backup has no manifest (created by an older version); its files cannot be verified.")
+	}
+
+	storeName := filepath.Base(storePath)
+	if restoreConfig {
+		if _, ok := archive.Files[backup.ConfigFile]; !ok {
+			return fmt.Errorf("backup does not contain %s", backup.ConfigFile)
+		}
+		fmt.Printf("Would overwrite: %s\n", backup.ConfigFile)
+	}
+	data, ok := archive.Files[storeName]
+	if !ok {
+		fmt.Println("The backup contains no store; no secrets would change.")
+		return nil
+	}
+	backedUp, err := store.OpenStore(data, keyFor)
+	if err != nil {
+		return fmt.Errorf("failed to open the backed-up store: %w", err)
+	}
+
+	current := &store.Store{}
+	targetStore := filepath.Join(targetDir, storeName)
+	if _, err := os.Stat(targetStore); err == nil {
+		if current, _, err = unlockStore(targetStore); err != nil {
+			return fmt.Errorf("failed to load the current store: %w", err)
+		}
+	}
+
+	changes := current.Diff(backedUp)
+	if changes.IsEmpty() {
+		fmt.Println("No secrets would change.")
+		return nil
+	}
+	fmt.Println("Restoring would change these secrets:")
+	for _, name := range changes.Added {
+		fmt.Printf("  + %s (added)\n", name)
+	}
+	for _, name := range changes.Changed {
+		fmt.Printf("  ~ %s (changed)\n", name)
+	}
+	for _, name := range changes.Removed {
+		fmt.Printf("  - %s (removed)\n", name)
+	}
+	fmt.Printf("%d added, %d changed, %d removed. Nothing was restored (dry run).\n",
+		len(changes.Added), len(changes.Changed), len(changes.Removed))
+	return nil
+}
+
+// checkSyncCredentials checks that the sync credentials in the config, which
+// are encrypted with the master password, still decrypt with the master
+// password of a store restored without its config. Only a store with another
+// salt than before can have another master password. If the credentials do
+// not decrypt, they are re-encrypted with the password they were encrypted
+// with, or the user is told to set up sync again.
+//
+// Parameters:
+//   - storePath: Path to the restored store
+//   - before: The header of the store before the restore (nil if there was none)
+func checkSyncCredentials(storePath string, before *store.Header) {
+	cfg, err := config.LoadConfig()
+	if err != nil || len(config.SyncCredentials(cfg)) == 0 {
+		return
+	}
+	after, err := store.ReadHeader(storePath)
+	if err != nil || (before != nil && bytes.Equal(before.Salt, after.Salt)) {
+		return
+	}
+
+	const hint = "sync fails until you run 'vlxck sync --init' again or restore with --config"
+	password := utils.PromptForPassword("Enter the master password of the restored store to check the sync credentials: ")
+	if _, _, err := unlockWithPassword(storePath, password); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: The sync credentials were not checked (%v); if they are encrypted with another master password, %s.\n", err, hint)
+		return
+	}
+	if config.CheckCredentials(cfg, password) == nil {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "The sync credentials in config.yaml are encrypted with another master password than the restored store.")
+	previous := utils.PromptForPassword("Enter that master password to re-encrypt them (empty to skip): ")
+	if previous == "" {
+		fmt.Fprintf(os.Stderr, "Warning: The sync credentials were not re-encrypted; %s.\n", hint)
+		return
+	}
+	if _, err := config.ReencryptCredentials(cfg, previous, password); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; %s.\n", err, hint)
+		return
+	}
+	if err := config.SaveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to save the re-encrypted sync credentials: %v; %s.\n", err, hint)
+		return
+	}
+	fmt.Println("Re-encrypted the sync credentials with the master password of the restored store.")
+}
+
+// backupKey returns the function that provides the key for an encrypted
+// backup and the store inside it. The cached store key is used if the backup
+// was encrypted with it, then keys derived earlier by the same function;
+// otherwise the master password or backup passphrase is asked for.
+//
+// Parameters:
+//   - storePath: Path to the store whose cached key to try
+//
+// Returns:
+//   - func(*store.Header) (*store.Key, error): The key function for backup.Restore
+func backupKey(storePath string) func(*store.Header) (*store.Key, error) {
+	var derived []*store.Key
+	return func(header *store.Header) (*store.Key, error) {
+		if key := cachedKey(storePath); key != nil && key.Matches(header) {
+			return key, nil
+		}
+		// A backup encrypted with the master password shares its salt with the store inside
+		for _, key := range derived {
+			if key.Matches(header) {
+				return key, nil
+			}
+		}
+		password := utils.PromptForPassword("Enter master password or backup passphrase: ")
+		key := header.Key(password)
+		derived = append(derived, key)
+		return key, nil
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(restoreCmd)
 
 	// Define command flags with shorthand and descriptions
 	restoreCmd.Flags().BoolP("interactive", "i", false, "Interactive mode to select from available backups")
+	restoreCmd.Flags().Bool("config", false, "Also restore config.yaml, including the sync settings and credentials")
+	restoreCmd.Flags().Bool("dry-run", false, "Show which secrets restoring would add, change or remove without restoring")
+	restoreCmd.Flags().StringSlice("secret", nil, "Restore only this secret into the current store (repeatable)")
+	restoreCmd.Flags().Bool("pick", false, "Choose the secrets to restore from the backup interactively")
+	restoreCmd.Flags().String("from", "", "Backup to restore secrets from (default: the newest backup)")
 }
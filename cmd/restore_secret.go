@@ -0,0 +1,209 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the restoring of single secrets from a backup into the
+// current store, used by 'vlxck restore --secret' and 'vlxck restore --pick'.
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/backup"
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// restoreSecrets restores the secrets named with --secret, or chosen with
+// --pick, from a backup into the current store. A secret that was changed
+// since the backup is a conflict and the user decides which version to keep;
+// when the backed-up version is chosen, the current value stays in the history.
+//
+// Parameters:
+//   - cmd: The restore command
+//   - args: The arguments of the restore command; the first is the backup file
+//   - interactive: Whether to choose the backup interactively
+//   - dryRun: Whether to only show what would be restored
+//
+// Returns:
+//   - error: Any error that occurred while opening the backup or saving the store
+func restoreSecrets(cmd *cobra.Command, args []string, interactive, dryRun bool) error {
+	names, err := cmd.Flags().GetStringSlice("secret")
+	if err != nil {
+		return fmt.Errorf("failed to get secret flag: %w", err)
+	}
+	pick, err := cmd.Flags().GetBool("pick")
+	if err != nil {
+		return fmt.Errorf("failed to get pick flag: %w", err)
+	}
+	from, err := cmd.Flags().GetString("from")
+	if err != nil {
+		return fmt.Errorf("failed to get from flag: %w", err)
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("a target directory cannot be used when restoring single secrets")
+	}
+
+	backupFile, err := secretBackupFile(from, args, interactive)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Backup file: %s\n", backupFile)
+
+	storePath := getStorePath()
+	backedUp, err := openBackedUpStore(backupFile, storePath)
+	if err != nil {
+		return err
+	}
+
+	var selected []store.Secret
+	for _, name := range names {
+		secret := backedUp.Find(name)
+		if secret == nil {
+			return fmt.Errorf("secret '%s' not found in the backup", name)
+		}
+		selected = append(selected, *secret)
+	}
+	if pick {
+		picked, err := pickSecrets(backedUp.Secrets)
+		if err != nil {
+			return err
+		}
+		selected = append(selected, picked...)
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no secrets selected")
+	}
+
+	s, key, err := unlockStore(storePath)
+	if err != nil {
+		return fmt.Errorf("failed to load the current store: %w", err)
+	}
+
+	restored := 0
+	for _, secret := range selected {
+		current := s.Find(secret.Name)
+		switch {
+		case current == nil:
+			if dryRun {
+				fmt.Printf("Would restore '%s' (not in the current store)\n", secret.Name)
+				continue
+			}
+			secret.UpdatedAt = time.Now()
+			s.Secrets = append(s.Secrets, secret)
+		case reflect.DeepEqual(*current, secret):
+			fmt.Printf("Secret '%s' is unchanged since the backup.\n", secret.Name)
+			continue
+		case dryRun:
+			fmt.Printf("Would ask which version of '%s' to keep (changed since the backup)\n", secret.Name)
+			continue
+		default:
+			if utils.PromptForConflictChoice(*current, secret) != "i" {
+				fmt.Printf("Kept the current version of '%s'.\n", secret.Name)
+				continue
+			}
+			// Replace the secret, keeping the current value in its history for rollback
+			history := current.History
+			value := current.Value
+			*current = secret
+			current.History = history
+			current.Value = value
+			current.SetValue(secret.Value)
+			current.UpdatedAt = time.Now()
+		}
+		restored++
+		fmt.Printf("Restored '%s'.\n", secret.Name)
+	}
+
+	if dryRun {
+		fmt.Println("Nothing was restored (dry run).")
+		return nil
+	}
+	if restored == 0 {
+		fmt.Println("No secrets restored.")
+		return nil
+	}
+
+	snapshotStore(storePath, "restore")
+	if err := store.SaveStoreWithKey(storePath, key, s); err != nil {
+		return fmt.Errorf("failed to save store: %w", err)
+	}
+	recordChange(storePath, "restore")
+
+	fmt.Printf("✓ Restored %d secrets from %s\n", restored, filepath.Base(backupFile))
+	return nil
+}
+
+// secretBackupFile returns the backup to restore secrets from: the one given
+// with --from or as argument, one chosen interactively, or the newest backup.
+func secretBackupFile(from string, args []string, interactive bool) (string, error) {
+	switch {
+	case from != "" && len(args) > 0:
+		return "", fmt.Errorf("give the backup file either with --from or as argument, not both")
+	case len(args) > 0:
+		from = args[0]
+	case interactive:
+		return selectBackup()
+	}
+	if from != "" {
+		path, err := filepath.Abs(from)
+		if err != nil {
+			return "", fmt.Errorf("invalid backup file path: %w", err)
+		}
+		return path, nil
+	}
+
+	backupDir := filepath.Join(filepath.Dir(getStorePath()), "backups")
+	backups, err := backup.ListBackups(backupDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to list backups: %w", err)
+	}
+	if len(backups) == 0 {
+		return "", fmt.Errorf("no backups found in %s", backupDir)
+	}
+	return backups[0].Path, nil
+}
+
+// openBackedUpStore opens and verifies the backup and decrypts the store inside it.
+func openBackedUpStore(backupFile, storePath string) (*store.Store, error) {
+	keyFor := backupKey(storePath)
+	archive, err := backup.Open(backupFile, keyFor)
+	if err != nil {
+		return nil, err
+	}
+	if !archive.Verified {
+		fmt.Println("Warning: The backup has no manifest (created by an older version); its files cannot be verified.")
+	}
+
+	data, ok := archive.Files[filepath.Base(storePath)]
+	if !ok {
+		return nil, fmt.Errorf("the backup contains no store")
+	}
+	backedUp, err := store.OpenStore(data, keyFor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the backed-up store: %w", err)
+	}
+	return backedUp, nil
+}
+
+// pickSecrets lets the user choose secrets of the backup one at a time until
+// they decline to pick another.
+func pickSecrets(secrets []store.Secret) ([]store.Secret, error) {
+	var picked []store.Secret
+	for {
+		secret, err := utils.PromptForSecret("Select secret to restore", secrets)
+		if err != nil {
+			return nil, fmt.Errorf("secret selection cancelled: %w", err)
+		}
+		picked = append(picked, secret)
+
+		more, err := utils.PromptForConfirm("Restore another secret")
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			return picked, nil
+		}
+	}
+}
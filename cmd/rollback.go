@@ -0,0 +1,99 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'rollback' command which is used to
+// restore a previous value of a secret.
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// rollbackCmd represents the 'rollback' command that restores a previous value of a secret.
+// The current value is kept in the history, so a rollback can be undone with another rollback.
+//
+// The command supports the following flags:
+//   - name (-n): The name of the secret (required)
+//   - to: The history entry to restore, as shown by 'vlxck history' (required)
+//   - yes (-y): Skip the confirmation prompt
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Restore a previous value of a secret",
+	Long: `Restore a previous value of a secret from its history.
+Entries are numbered as shown by 'vlxck history', where 1 is the most recent
+previous value. The current value is kept in the history.
+
+Examples:
+  # Restore the value before the last change
+  vlxck rollback -n example.com --to 1`,
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		to, _ := cmd.Flags().GetInt("to")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		filePath := getStorePath()
+		s, key, err := unlockStore(filePath)
+		if err != nil {
+			fmt.Println("Error loading store:", err)
+			return
+		}
+
+		for i := range s.Secrets {
+			secret := &s.Secrets[i]
+			if secret.Name != name {
+				continue
+			}
+
+			if to < 1 || to > len(secret.History) {
+				fmt.Printf("Error: history entry %d does not exist (secret '%s' has %d)\n", to, name, len(secret.History))
+				return
+			}
+
+			if !yes {
+				changedAt := secret.History[to-1].ChangedAt.Format("2006-01-02 15:04:05")
+				confirm, err := utils.PromptForConfirm(fmt.Sprintf("Restore the value of '%s' replaced at %s", name, changedAt))
+				if err != nil {
+					fmt.Println("Error:", err)
+					return
+				}
+				if !confirm {
+					fmt.Println("Rollback cancelled.")
+					return
+				}
+			}
+
+			if err := secret.Rollback(to); err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			secret.UpdatedAt = time.Now()
+
+			snapshotStore(filePath, "rollback")
+			if err := store.SaveStoreWithKey(filePath, key, s); err != nil {
+				fmt.Println("Error saving store:", err)
+				return
+			}
+
+			recordChange(filePath, "rollback")
+			fmt.Printf("Secret '%s' rolled back successfully.\n", name)
+			return
+		}
+		fmt.Printf("Secret '%s' not found.\n", name)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	// Define command flags with shorthand and descriptions
+	rollbackCmd.Flags().StringP("name", "n", "", "Name of the secret (required)")
+	rollbackCmd.Flags().Int("to", 0, "History entry to restore, as shown by 'vlxck history' (required)")
+	rollbackCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+
+	// Mark required flags
+	rollbackCmd.MarkFlagRequired("name")
+	rollbackCmd.MarkFlagRequired("to")
+}
@@ -10,15 +10,18 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/kirinyoku/vlxck/internal/agent"
 	"github.com/kirinyoku/vlxck/internal/cache"
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/output"
+	"github.com/kirinyoku/vlxck/internal/snapshot"
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/sync"
 	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/spf13/cobra"
 )
 
-const (
-	Version      = "0.8.2"         // Version of the application
-	cacheTimeout = 5 * time.Minute // Fixed 5-minute cache timeout
-)
+const Version = "0.8.2" // Version of the application
 
 // getStorePath returns the path to the encrypted store file.
 func getStorePath() string {
@@ -26,32 +29,189 @@ func getStorePath() string {
 	return filepath.Join(homeDir, ".vlxck", "store.dat")
 }
 
-// getPassword retrieves the password from cache or prompts the user
-// The password will be cached for 5 minutes after successful verification
-// by the caller using cacheVerifiedPassword.
-func getPassword(cacheOnSuccess bool) (string, error) {
-	// Try to get password from cache
-	if !cacheOnSuccess {
-		password, err := cache.GetMasterPassword()
-		if err != nil {
-			// Log but don't fail - we'll prompt for password
-			fmt.Fprintf(os.Stderr, "Warning: Failed to read password cache: %v\n", err)
-		} else if password != "" {
-			return password, nil
+// enteredPassword holds the master password once it was entered, so that
+// auto-sync and the command itself ask for it only once per run.
+var (
+	enteredPassword  string
+	passwordPrompted bool
+)
+
+// getPassword prompts the user for the master password, at most once per run.
+// The password itself is never cached across runs. Commands that only need
+// to open the store should use unlockStore, which reuses a key held by the
+// unlock agent or the key cache before falling back to getPassword.
+func getPassword() (string, error) {
+	if !passwordPrompted {
+		enteredPassword = utils.PromptForPassword("Enter master password: ")
+		passwordPrompted = true
+	}
+	return enteredPassword, nil
+}
+
+// cacheTimeout returns how long the derived store key is cached: the value of
+// the --cache-timeout flag if given, otherwise cache.timeout from the config
+// file, otherwise config.DefaultCacheTimeout.
+func cacheTimeout() time.Duration {
+	if flag := rootCmd.PersistentFlags().Lookup("cache-timeout"); flag != nil && flag.Changed {
+		timeout, _ := rootCmd.PersistentFlags().GetDuration("cache-timeout")
+		return timeout
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load config: %v\n", err)
+		return config.DefaultCacheTimeout
+	}
+	return cfg.Cache.Timeout
+}
+
+// keyCache returns the key cache backend selected with cache.backend in the
+// config file, falling back to the file backend if it cannot be used.
+func keyCache() cache.Backend {
+	name := config.DefaultCacheBackend
+	if cfg, err := config.LoadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load config: %v\n", err)
+	} else {
+		name = cfg.Cache.Backend
+	}
+	backend, err := cache.New(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using %s\n", err, cache.BackendFile)
+		backend, _ = cache.New(cache.BackendFile)
+	}
+	return backend
+}
+
+// unlockStore loads the store for a command. The unlock agent is asked for the
+// store key first, then the key cache; only if neither holds a matching key
+// is the master password obtained via getPassword. A key derived from the
+// password is remembered so that later commands skip the prompt.
+//
+// Parameters:
+//   - filePath: Path to the encrypted store file
+//
+// Returns:
+//   - *store.Store: The decrypted store
+//   - *store.Key: The key to save the store with
+//   - error: Any error that occurred while unlocking or loading the store
+func unlockStore(filePath string) (*store.Store, *store.Key, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("store file %s does not exist", filePath)
+	}
+
+	agentClient := agent.NewClient(agent.SocketPath())
+	if key, err := agentClient.GetKey(filePath); err == nil && key != nil {
+		if s, err := store.LoadStoreWithKey(filePath, key); err == nil {
+			return s, key, nil
 		}
+		// The store was rekeyed or its password changed; drop the stale key
+		agentClient.Forget(filePath)
 	}
 
-	// Not in cache or cache disabled, prompt user
-	password := utils.PromptForPassword("Enter master password: ")
-	return password, nil
+	keyCache := keyCache()
+	key, err := keyCache.Get(filePath)
+	if err != nil {
+		// Log but don't fail - we'll prompt for password
+		fmt.Fprintf(os.Stderr, "Warning: Failed to read key cache: %v\n", err)
+	} else if key != nil {
+		if s, err := store.LoadStoreWithKey(filePath, key); err == nil {
+			return s, key, nil
+		}
+		keyCache.Clear()
+	}
+
+	password, err := getPassword()
+	if err != nil {
+		return nil, nil, err
+	}
+	return unlockWithPassword(filePath, password)
 }
 
-// cacheVerifiedPassword caches the password for 5 minutes
-func cacheVerifiedPassword(password string) {
-	if err := cache.SetMasterPassword(password, cacheTimeout); err != nil {
-		// Log but don't fail - the password was still obtained
-		fmt.Fprintf(os.Stderr, "Warning: Failed to cache password: %v\n", err)
+// unlockWithPassword loads the store with the master password, then
+// remembers the derived key with rememberKey.
+func unlockWithPassword(filePath, password string) (*store.Store, *store.Key, error) {
+	key, err := store.DeriveKey(filePath, password)
+	if err != nil {
+		return nil, nil, err
 	}
+	s, err := store.LoadStoreWithKey(filePath, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	rememberKey(filePath, key)
+	return s, key, nil
+}
+
+// rememberKey hands a verified key to the agent. Only when no agent is
+// running is the key written to the key cache for cacheTimeout.
+func rememberKey(filePath string, key *store.Key) {
+	if err := agent.NewClient(agent.SocketPath()).SetKey(filePath, key); err == nil {
+		return
+	}
+	if err := keyCache().Set(filePath, key, cacheTimeout()); err != nil {
+		// Log but don't fail - the store was still unlocked
+		fmt.Fprintf(os.Stderr, "Warning: Failed to cache key: %v\n", err)
+	}
+}
+
+// forgetKey makes the agent and all key cache backends drop the key for the
+// store, e.g. after the master password was changed or the store was rekeyed.
+func forgetKey(filePath string) {
+	agent.NewClient(agent.SocketPath()).Forget(filePath)
+	if err := cache.ClearAll(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to clear key cache: %v\n", err)
+	}
+}
+
+// snapshotStore keeps a copy of the store before the command modifies it, so
+// that 'vlxck undo' can revert the change. Failing to take the snapshot is
+// reported but does not stop the command.
+func snapshotStore(filePath, command string) {
+	snapshotStoreWith(filePath, command, nil)
+}
+
+// snapshotStoreWith is snapshotStore for commands that change more than the
+// store; the attachment is kept with the snapshot for undo to bring back.
+func snapshotStoreWith(filePath, command string, attachment []byte) {
+	keep := config.DefaultSnapshotKeep
+	if cfg, err := config.LoadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load config: %v\n", err)
+	} else {
+		keep = cfg.Snapshots.Keep
+	}
+	if err := snapshot.Take(filePath, command, keep, attachment); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to snapshot store: %v\n", err)
+	}
+}
+
+// recordChange records a saved change of the store with the sync provider,
+// which for git sync commits the store to the local repository, and pushes
+// the store if auto-sync is enabled. The message only names the command,
+// never a secret, since it ends up on the remote. Failing to record or push
+// is not fatal: the change is picked up by the next sync.
+func recordChange(filePath, command string) {
+	if err := sync.Record(filePath, "vlxck "+command); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to record change for sync: %v\n", err)
+	}
+	autoPush(filePath)
+}
+
+// outputFormat returns the format selected with the global --output flag.
+// The flag is read from the root command so that commands may define their
+// own --output flag (e.g. inject's output file) without affecting the format.
+func outputFormat(cmd *cobra.Command) (output.Format, error) {
+	name, _ := cmd.Root().PersistentFlags().GetString("output")
+	return output.ParseFormat(name)
+}
+
+// revealValues reports whether the global --reveal flag was passed.
+func revealValues(cmd *cobra.Command) bool {
+	reveal, _ := cmd.Root().PersistentFlags().GetBool("reveal")
+	return reveal
+}
+
+// writeOutput renders the result to stdout in the given structured format.
+func writeOutput(format output.Format, result output.Tabular) error {
+	return output.Write(os.Stdout, format, result)
 }
 
 // rootCmd is the root command for the application.
@@ -69,11 +229,25 @@ Getting Started:
 
 Security:
   • All data is encrypted before being written to disk
-  • Master password is never stored
+  • Master password is never stored; only the derived key is cached
+    (see --cache-timeout and 'vlxck lock')
   • Uses industry-standard encryption (AES-256-GCM with Argon2id key derivation)
 
+Scripting:
+  list, get, list-backups, search and generate accept --output json|yaml|tsv
+  for machine-readable output. Secret values stay hidden unless --reveal is passed.
+
 For more information about a specific command, use 'vlxck [command] --help'
 `,
+	// Reject unknown output formats before any command runs, then pull the
+	// store if auto-sync is enabled
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := outputFormat(cmd); err != nil {
+			return err
+		}
+		autoPull(cmd)
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -88,6 +262,9 @@ func init() {
 	rootCmd.Version = Version
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "Print the version number")
 	rootCmd.PersistentFlags().BoolP("toggle", "t", false, "Help message for toggle")
+	rootCmd.PersistentFlags().String("output", "text", "Output format for read commands: text, json, yaml or tsv")
+	rootCmd.PersistentFlags().Bool("reveal", false, "Include secret values in json, yaml and tsv output")
+	rootCmd.PersistentFlags().Duration("cache-timeout", config.DefaultCacheTimeout, "How long to cache the unlocked store key (0 disables caching; default from cache.timeout in config)")
 
 	// Clear the cache on application exit
 	// This ensures we don't leave sensitive data in the cache if the program crashes
@@ -97,7 +274,7 @@ func init() {
 		c := make(chan os.Signal, 1)
 		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 		<-c
-		cache.ClearMasterPassword()
+		cache.ClearAll()
 		os.Exit(0)
 	}()
 }
@@ -0,0 +1,164 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'run' command which is used to
+// run a program with secrets injected as environment variables.
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// envNamePattern matches valid environment variable names.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// forwardedSignals are passed on to the child process.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// runCmd represents the 'run' command that executes a program with secrets
+// set as environment variables. The store is unlocked once, every reference is
+// resolved before the program starts, and the values never appear in argv.
+// Signals received by vlxck are forwarded to the program, and vlxck exits with
+// the program's exit code.
+//
+// The command supports the following flags:
+//   - env (-e): VAR=reference pair (repeatable), where reference is
+//     [vlxck://]secret[#field] and secret is a name or folder/name
+var runCmd = &cobra.Command{
+	Use:   "run --env VAR=secret[#field] [--env ...] -- COMMAND [ARGS...]",
+	Short: "Run a command with secrets as environment variables",
+	Long: `Run a command with secrets injected as environment variables, so that
+credentials never have to be written to .env files or passed in argv.
+
+Each --env maps a variable to a secret reference: the secret name or its
+folder/name path, optionally followed by #field (value by default), e.g.
+prod/db#username. All references are resolved before the command starts.
+Signals are forwarded to the command and its exit code is returned.
+
+Examples:
+  # Start a server with a database password and an API key
+  vlxck run --env DB_PASS=prod/db --env API_KEY=stripe -- ./server
+
+  # Use another field of the secret
+  vlxck run -e DB_USER=prod/db#username -e DB_PASS=prod/db -- psql`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		specs, _ := cmd.Flags().GetStringArray("env")
+		if len(specs) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: at least one --env VAR=secret is required")
+			os.Exit(1)
+		}
+
+		filePath := getStorePath()
+		s, _, err := unlockStore(filePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading store:", err)
+			os.Exit(1)
+		}
+
+		env, err := resolveEnv(s, specs)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+
+		os.Exit(runChild(args, env))
+	},
+}
+
+// resolveEnv resolves VAR=reference specifications into VAR=value entries.
+//
+// Parameters:
+//   - s: The unlocked store
+//   - specs: The VAR=reference specifications
+//
+// Returns:
+//   - []string: The environment entries
+//   - error: An error if a specification is invalid or a reference cannot be resolved
+func resolveEnv(s *store.Store, specs []string) ([]string, error) {
+	env := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		name, refSpec, ok := strings.Cut(spec, "=")
+		if !ok || !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid --env '%s': expected VAR=secret[#field]", spec)
+		}
+		ref, err := store.ParseReference(refSpec)
+		if err != nil {
+			return nil, err
+		}
+		value, err := s.Resolve(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
+
+// runChild runs the command with the extra environment, forwarding signals
+// until it exits.
+//
+// Parameters:
+//   - args: The command and its arguments
+//   - env: Extra environment entries, which override inherited ones
+//
+// Returns:
+//   - int: The exit code to exit with (128+signal if the command was killed by a signal)
+func runChild(args []string, env []string) int {
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = append(os.Environ(), env...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	// Take over SIGINT/SIGTERM from the root handler, which would exit
+	// immediately: the child decides how to react and we report its status.
+	signal.Reset(forwardedSignals...)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := child.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 127
+	}
+
+	go func() {
+		for sig := range signals {
+			_ = child.Process.Signal(sig)
+		}
+	}()
+
+	err := child.Wait()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitErr.ExitCode()
+	default:
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	// Define command flags with shorthand and descriptions
+	runCmd.Flags().StringArrayP("env", "e", nil, "Environment variable to set as VAR=secret[#field] (repeatable)")
+
+	// Everything after the command name belongs to the command
+	runCmd.Flags().SetInterspersed(false)
+}
@@ -0,0 +1,101 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'search' command which is used to
+// find secrets by fuzzy matching.
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kirinyoku/vlxck/internal/output"
+	"github.com/kirinyoku/vlxck/internal/search"
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// searchCmd represents the 'search' command that finds secrets by fuzzy matching
+// the query against their name, category, username and URLs.
+// Results are ranked: exact and prefix matches first, then substring matches,
+// then scattered matches such as "gthb" for "github". Name matches outrank
+// matches in the metadata. Every word of the query must match.
+//
+// The command supports the following flags:
+//   - category (-c): Optional category folder to search in (includes subfolders)
+//   - tag: Optional tag expression for filtering secrets (repeatable, prefix with ! to exclude)
+//   - limit (-l): Maximum number of results to show (0 for all)
+var searchCmd = &cobra.Command{
+	Use:   "search QUERY",
+	Short: "Search secrets by name, category, username and URL",
+	Long: `Search secrets with ranked fuzzy matching over their name, category,
+username and URLs. Every word of the query must match; the best matches are shown first.
+
+Examples:
+  # Find secrets related to GitHub
+  vlxck search github
+
+  # Characters only need to appear in order
+  vlxck search gthb
+
+  # Combine words to narrow down the results
+  vlxck search work aws
+
+  # Search within a folder, showing the top 5 results
+  vlxck search db -c work --limit 5
+
+  # Print the ranked matches as TSV
+  vlxck search github --output tsv`,
+	Args: cobra.MinimumNArgs(1),
+	// Errors go to stderr with a non-zero exit code, as for list
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := strings.Join(args, " ")
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		filePath := getStorePath()
+		s, _, err := unlockStore(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to load store: %w", err)
+		}
+
+		filter, err := secretFilterFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		matches := search.Rank(filter.Apply(s.Secrets), query)
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit > 0 && len(matches) > limit {
+			matches = matches[:limit]
+		}
+
+		results := make([]store.Secret, len(matches))
+		for i, match := range matches {
+			results[i] = match.Secret
+		}
+
+		// Machine-readable output keeps the ranking order
+		if format.IsStructured() {
+			return writeOutput(format, output.NewSecrets(results, revealValues(cmd)))
+		}
+
+		if len(results) == 0 {
+			fmt.Printf("No secrets match '%s'.\n", query)
+			return nil
+		}
+		paginateSecrets(results)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	// Define command flags with shorthand and descriptions
+	searchCmd.Flags().StringP("category", "c", "", "Search within a category folder (includes subfolders)")
+	searchCmd.Flags().StringSlice("tag", nil, "Filter by tag, prefix with ! to exclude (repeatable or comma-separated)")
+	searchCmd.Flags().IntP("limit", "l", 0, "Maximum number of results to show (0 for all)")
+}
@@ -0,0 +1,61 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'snapshots' command which is used to
+// list the snapshots of the store that 'vlxck undo' can revert to.
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kirinyoku/vlxck/internal/output"
+	"github.com/kirinyoku/vlxck/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+// snapshotsCmd represents the 'snapshots' command that lists the snapshots
+// taken before store changes, newest first, with the command that made each change.
+var snapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "List the snapshots that undo can revert to",
+	Long: `List the snapshots of the secret store taken before changes, newest first.
+Each snapshot shows the command that changed the store after it was taken;
+'vlxck undo' reverts the change listed first.
+
+The number of snapshots kept is set with snapshots.keep in ~/.vlxck/config.yaml.
+
+Examples:
+  # List snapshots
+  vlxck snapshots
+
+  # Print snapshots as JSON for scripts
+  vlxck snapshots --output json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		snapshots, err := snapshot.List(getStorePath())
+		if err != nil {
+			return err
+		}
+
+		if format.IsStructured() {
+			return writeOutput(format, output.NewSnapshots(snapshots))
+		}
+
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots found.")
+			return nil
+		}
+
+		for i, s := range snapshots {
+			fmt.Printf("%d. %s  before 'vlxck %s'\n", i+1, s.CreatedAt.Format("2006-01-02 15:04:05"), s.Command)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotsCmd)
+}
@@ -1,11 +1,12 @@
 // Package cmd implements the command-line interface for the secure secret manager.
 // This file contains the implementation of the 'sync' command which is used to
-// synchronize the secret store with Google Drive.
+// synchronize the secret store with a remote storage provider.
 package cmd
 
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/kirinyoku/vlxck/internal/sync"
 	"github.com/kirinyoku/vlxck/internal/utils"
@@ -13,26 +14,55 @@ import (
 )
 
 // syncCmd represents the sync command
-// It synchronizes the secret store with Google Drive.
+// It synchronizes the secret store with the provider configured in sync.provider.
 // The command requires the following flags:
 //   - mode (-m): The sync mode, either 'push' or 'pull'
-//   - init: Initialize Google Drive sync
+//   - init: Initialize sync with a provider
+//   - provider: The provider to initialize (google_drive by default)
+//   - auto: Turn automatic sync around store commands on or off
 var syncCmd = &cobra.Command{
 	Use: "sync",
-	Short: `Synchronize the secret store with Google Drive
+	Short: `Synchronize the secret store with a remote provider
 
 Examples:
   # Initialize Google Drive sync
   vlxck sync --init
 
-  # Push changes to Google Drive
+  # Initialize sync with a WebDAV server such as Nextcloud
+  vlxck sync --init --provider webdav
+
+  # Initialize sync with AWS S3 or MinIO
+  vlxck sync --init --provider s3
+
+  # Initialize sync with a git repository
+  vlxck sync --init --provider git
+
+  # Push changes to the configured provider
   vlxck sync -m push
 
-  # Pull changes from Google Drive
-  vlxck sync -m pull`,
+  # Pull changes from the configured provider, merging them with local changes
+  vlxck sync -m pull
+
+  # Show whether the store is ahead of, behind or diverged from the remote copy
+  vlxck sync status
+
+  # Pull before and push after every command that uses the store
+  vlxck sync --auto
+
+  # Turn automatic sync off again
+  vlxck sync --auto=false`,
 	Run: func(cmd *cobra.Command, args []string) {
 		mode, _ := cmd.Flags().GetString("mode")
 		initialize, _ := cmd.Flags().GetBool("init")
+		providerName, _ := cmd.Flags().GetString("provider")
+
+		if cmd.Flags().Changed("auto") {
+			auto, _ := cmd.Flags().GetBool("auto")
+			if err := setAutoSync(auto); err != nil {
+				fmt.Println("Error:", err)
+			}
+			return
+		}
 
 		storePath := getStorePath()
 		masterPassword := utils.PromptForPassword("Enter master password: ")
@@ -40,12 +70,12 @@ Examples:
 		ctx := context.Background()
 
 		if initialize {
-			cfg, err := sync.InitGoogleDrive(ctx, masterPassword)
+			name, err := sync.Init(ctx, storePath, providerName, masterPassword)
 			if err != nil {
 				fmt.Printf("Error initializing sync: %v\n", err)
 				return
 			}
-			fmt.Printf("Sync initialized successfully. File ID: %s\n", cfg.Sync.FileID)
+			fmt.Printf("Sync initialized successfully with provider %s.\n", name)
 			return
 		}
 
@@ -55,10 +85,15 @@ Examples:
 			return
 		}
 
-		if err := manager.Sync(mode); err != nil {
+		if err := manager.Sync(sync.Mode(mode)); err != nil {
 			fmt.Printf("Error syncing: %v\n", err)
-		} else {
-			fmt.Println("Sync completed successfully")
+			return
+		}
+		fmt.Println("Sync completed successfully")
+		if sync.Mode(mode) == sync.ModePull {
+			if changed, err := manager.LocalChanges(); err == nil && changed {
+				fmt.Println("The merged store has local changes; push to upload them.")
+			}
 		}
 	},
 }
@@ -68,5 +103,7 @@ func init() {
 
 	// Define command flags with shorthand and descriptions
 	syncCmd.Flags().StringP("mode", "m", "", "Sync mode: push, pull")
-	syncCmd.Flags().Bool("init", false, "Initialize Google Drive sync")
+	syncCmd.Flags().Bool("init", false, "Initialize sync with a provider")
+	syncCmd.Flags().Bool("auto", false, "Turn automatic sync on (--auto) or off (--auto=false)")
+	syncCmd.Flags().String("provider", "", fmt.Sprintf("Provider to initialize with --init: %s (default: configured provider or %s)", strings.Join(sync.Providers(), ", "), sync.DefaultProvider))
 }
@@ -0,0 +1,86 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'sync status' command which is used to
+// compare the local secret store with the remote copy.
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// syncStatusDescriptions explains each sync state in the status output.
+var syncStatusDescriptions = map[sync.State]string{
+	sync.StateUpToDate:  "no changes since the last sync",
+	sync.StateAhead:     "local changes not pushed yet",
+	sync.StateBehind:    "remote changes not pulled yet",
+	sync.StateDiverged:  "local and remote changes; pull to merge them, then push",
+	sync.StateNotSynced: "the store was never pushed or pulled",
+}
+
+// syncStatusCmd represents the sync status command
+// It reports whether the local store is ahead of, behind or diverged from
+// the remote copy, when it was last synced and when the remote copy was
+// last modified.
+var syncStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the secret store is in sync with the remote copy",
+	Long: `Compare the local secret store and the remote copy with the state of the last sync.
+
+The store is ahead if it changed locally since the last push or pull, behind if
+another device pushed since then, and diverged if both happened.
+
+Examples:
+  # Show the sync status
+  vlxck sync status`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			fmt.Println("Error loading config:", err)
+			return
+		}
+		// The status never decrypts the store, so the master password is
+		// only needed to decrypt the provider's credentials
+		masterPassword, err := syncPassword(cfg.Sync.Provider, false)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		manager, err := sync.NewSyncManager(context.Background(), getStorePath(), masterPassword)
+		if err != nil {
+			fmt.Printf("Error creating sync manager: %v\n", err)
+			return
+		}
+
+		status, err := manager.Status()
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		fmt.Printf("Provider:        %s\n", status.Provider)
+		fmt.Printf("Status:          %s (%s)\n", status.State, syncStatusDescriptions[status.State])
+		if status.LastSync.IsZero() {
+			fmt.Println("Last sync:       never")
+		} else {
+			fmt.Printf("Last sync:       %s\n", status.LastSync.Local().Format("2006-01-02 15:04:05"))
+		}
+		switch {
+		case status.Remote == nil:
+			fmt.Println("Remote modified: no remote copy yet")
+		case status.Remote.ModifiedAt.IsZero():
+			fmt.Println("Remote modified: unknown")
+		default:
+			fmt.Printf("Remote modified: %s\n", status.Remote.ModifiedAt.Local().Format("2006-01-02 15:04:05"))
+		}
+	},
+}
+
+func init() {
+	syncCmd.AddCommand(syncStatusCmd)
+}
@@ -0,0 +1,135 @@
+// Package cmd implements the command-line interface for the secure secret manager.
+// This file contains the implementation of the 'undo' command which is used to
+// revert the store to the snapshot taken before the last change.
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/snapshot"
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+// undoCmd represents the 'undo' command that reverts the store to the state
+// before the last change. The snapshot is used up, so running undo again
+// reverts the change before that.
+//
+// The command supports the following flags:
+//   - yes (-y): Skip the confirmation prompt
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the store to the state before the last change",
+	Long: `Revert the secret store to the snapshot taken before the last change.
+
+A snapshot of the store is taken before add, update, delete, rollback, import,
+change-master, rekey and restore modify it. Undo replaces the store with the
+newest snapshot and deletes that snapshot, so running it again steps further
+back. Undoing change-master or rekey brings back the previous master password
+or key derivation settings; undoing change-master also brings back the sync
+credentials encrypted with the previous password. Use 'vlxck snapshots' to see
+what can be undone.
+
+Examples:
+  # Revert the last change
+  vlxck undo
+
+  # Revert without asking for confirmation
+  vlxck undo -y`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		filePath := getStorePath()
+		snapshots, err := snapshot.List(filePath)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("Nothing to undo.")
+			return
+		}
+		last := snapshots[0]
+		takenAt := last.CreatedAt.Format("2006-01-02 15:04:05")
+
+		if !yes {
+			confirm, err := utils.PromptForConfirm(fmt.Sprintf("Undo 'vlxck %s' from %s", last.Command, takenAt))
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			if !confirm {
+				fmt.Println("Undo cancelled.")
+				return
+			}
+		}
+
+		attachment, err := last.Attachment()
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if err := snapshot.Restore(filePath, last); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if last.Command == "change-master" {
+			restoreSyncCredentials(attachment)
+		}
+
+		// Undoing change-master or rekey brings back the old salt, so a cached key no longer matches
+		if key := cachedKey(filePath); key != nil {
+			if header, err := store.ReadHeader(filePath); err != nil || !key.Matches(header) {
+				forgetKey(filePath)
+			}
+		}
+
+		recordChange(filePath, "undo")
+		fmt.Printf("Reverted 'vlxck %s' from %s.\n", last.Command, takenAt)
+	},
+}
+
+// restoreSyncCredentials brings back the sync credentials kept with the
+// snapshot of change-master, which are encrypted with the master password of
+// the reverted store. Without them, e.g. for snapshots of older versions, the
+// credentials may not match the reverted store and the user is warned.
+//
+// Parameters:
+//   - attachment: The credentials kept with the snapshot (nil if none)
+func restoreSyncCredentials(attachment []byte) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load config: %v; run 'vlxck sync --init' if sync fails\n", err)
+		return
+	}
+	if attachment == nil {
+		if len(config.SyncCredentials(cfg)) > 0 {
+			fmt.Fprintln(os.Stderr, "Warning: The snapshot holds no sync credentials; they may still be encrypted with the newer master password. Run 'vlxck sync --init' if sync fails.")
+		}
+		return
+	}
+
+	var credentials config.Credentials
+	if err := json.Unmarshal(attachment, &credentials); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to read the sync credentials of the snapshot: %v; run 'vlxck sync --init' again\n", err)
+		return
+	}
+	config.SetSyncCredentials(cfg, credentials)
+	if err := config.SaveConfig(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to restore the sync credentials: %v; run 'vlxck sync --init' again\n", err)
+		return
+	}
+	fmt.Println("Restored the sync credentials of the previous master password.")
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+
+	// Define command flags with shorthand and descriptions
+	undoCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+}
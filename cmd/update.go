@@ -5,8 +5,10 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/totp"
 	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/spf13/cobra"
 )
@@ -21,7 +23,14 @@ import (
 // The command supports the following flags:
 //   - name (-n): The name/identifier of the secret (required in non-interactive mode)
 //   - value (-v): The new secret value (or use -g to generate)
-//   - category (-c): The new category for the secret (use "-" to keep existing)
+//   - category (-c): The new category folder for the secret (use "-" to keep existing)
+//   - tag: Replacement tags (repeatable)
+//   - add-tag, remove-tag: Add or remove individual tags (repeatable)
+//   - username (-u): The new username (use "-" to keep existing)
+//   - url: Replacement URLs (repeatable)
+//   - notes: The new notes (use "-" to keep existing)
+//   - field (-f): Add or replace a custom field as [type:]name=value (repeatable)
+//   - remove-field: Remove a custom field by name (repeatable)
 //   - generate (-g): Generate a new random password for the secret
 //   - length (-l): Length of generated password (default: 16)
 //   - symbols (-s): Include symbols in generated password
@@ -37,6 +46,8 @@ In non-interactive mode, you must specify the secret name and at least one of:
 - A new value (--value/-v)
 - The --generate flag to create a new password
 - A new category (--category/-c)
+- New tags (--tag, --add-tag, --remove-tag)
+- New metadata (--username/-u, --url, --notes, --field/-f, --remove-field)
 
 Examples:
   # Interactive mode
@@ -49,20 +60,14 @@ Examples:
   vlxck update -n example.com -v newpassword -c work
 
   # Generate a 24-char password with symbols and digits
-  vlxck update -n example.com -gdsl 24`,
+  vlxck update -n example.com -gdsl 24
+
+  # Change the username and replace a custom field
+  vlxck update -n example.com -u alice -f hidden:pin=4321`,
 
 	Run: func(cmd *cobra.Command, args []string) {
 		filePath := getStorePath()
-		password, err := getPassword(false)
-		if err != nil {
-			fmt.Println("Error:", err)
-			return
-		}
-		s, err := store.LoadStore(filePath, password)
-		if err == nil {
-			// Only cache the password if it was successfully used
-			cacheVerifiedPassword(password)
-		}
+		s, key, err := unlockStore(filePath)
 		if err != nil {
 			fmt.Println("Error loading store:", err)
 			return
@@ -71,34 +76,30 @@ Examples:
 		// Check for interactive mode first
 		interactive, _ := cmd.Flags().GetBool("interactive")
 		if interactive {
-			updateInteractive(s, filePath, password)
+			updateInteractive(s, filePath, key)
 			return
 		}
 
 		// Non-interactive mode
-		updateNonInteractive(cmd, s, filePath, password)
+		updateNonInteractive(cmd, s, filePath, key)
 	},
 }
 
 // updateInteractive handles the interactive update flow
-func updateInteractive(s *store.Store, filePath, password string) {
+func updateInteractive(s *store.Store, filePath string, key *store.Key) {
 	// Show list of secrets for user to choose from
 	if len(s.Secrets) == 0 {
 		fmt.Println("No secrets found to update.")
 		return
 	}
 
-	// Get secret name from user
-	secretNames := make([]string, 0, len(s.Secrets))
-	for _, secret := range s.Secrets {
-		secretNames = append(secretNames, secret.Name)
-	}
-
-	selectedName, err := utils.PromptForSelect("Select secret to update", secretNames)
+	// Get secret from user; typing filters the list
+	selected, err := utils.PromptForSecret("Select secret to update", s.Secrets)
 	if err != nil {
 		fmt.Println("Error selecting secret:", err)
 		return
 	}
+	selectedName := selected.Name
 
 	// Find the selected secret
 	var secretToUpdate *store.Secret
@@ -115,7 +116,7 @@ func updateInteractive(s *store.Store, filePath, password string) {
 	}
 
 	// Ask what to update
-	updateOptions := []string{"Update value", "Update category", "Update both", "Cancel"}
+	updateOptions := []string{"Update value", "Update category", "Update both", "Update details", "Cancel"}
 	action, err := utils.PromptForSelect("What would you like to update?", updateOptions)
 	if err != nil || action == "Cancel" {
 		return
@@ -123,11 +124,15 @@ func updateInteractive(s *store.Store, filePath, password string) {
 
 	// Handle value update if needed
 	if action == "Update value" || action == "Update both" {
-		updateValue, err := utils.PromptForSelect("Choose value input method",
-			[]string{"Enter new value", "Generate password"})
-		if err != nil {
-			fmt.Println("Error:", err)
-			return
+		// A generated password is no TOTP seed, so seeds can only be entered
+		updateValue := "Enter new value"
+		if !secretToUpdate.IsTOTP() {
+			updateValue, err = utils.PromptForSelect("Choose value input method",
+				[]string{"Enter new value", "Generate password"})
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
 		}
 
 		if updateValue == "Generate password" {
@@ -163,7 +168,19 @@ func updateInteractive(s *store.Store, filePath, password string) {
 				fmt.Println("Generated password copied to clipboard.")
 			}
 
-			secretToUpdate.Value = value
+			secretToUpdate.SetValue(value)
+		} else if secretToUpdate.IsTOTP() {
+			// TOTP seeds are validated and kept in canonical form
+			value, err := utils.PromptForInput("Enter new TOTP seed", "", func(input string) error {
+				_, err := totp.NormalizeSeed(input)
+				return err
+			})
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			seed, _ := totp.NormalizeSeed(value)
+			secretToUpdate.SetValue(seed)
 		} else {
 			// Prompt for new value
 			value, err := utils.PromptForInput("Enter new value", "", func(input string) error {
@@ -176,23 +193,71 @@ func updateInteractive(s *store.Store, filePath, password string) {
 				fmt.Println("Error:", err)
 				return
 			}
-			secretToUpdate.Value = value
+			secretToUpdate.SetValue(value)
 		}
 	}
 
 	// Handle category update if needed
 	if action == "Update category" || action == "Update both" {
-		category, err := utils.PromptForInput("Enter new category (leave empty to remove)",
+		category, err := utils.PromptForInput("Enter new category folder (leave empty to remove)",
 			secretToUpdate.Category, nil)
 		if err != nil {
 			fmt.Println("Error:", err)
 			return
 		}
-		secretToUpdate.Category = category
+		secretToUpdate.Category = store.NormalizeFolder(category)
+	}
+
+	// Handle username, URLs, notes and custom fields
+	if action == "Update details" {
+		username, err := utils.PromptForInput("Enter username (leave empty to remove)", secretToUpdate.Username, nil)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		urls, err := utils.PromptForURLs(secretToUpdate.URLs)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		notes, err := utils.PromptForInput("Enter notes (leave empty to remove)", secretToUpdate.Notes, nil)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		tags, err := utils.PromptForTags(secretToUpdate.Tags)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		secretToUpdate.Tags = tags
+		secretToUpdate.Username = username
+		secretToUpdate.URLs = urls
+		secretToUpdate.Notes = notes
+
+		for {
+			more, err := utils.PromptForConfirm("Add or replace a custom field")
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			if !more {
+				break
+			}
+			field, err := utils.PromptForCustomField()
+			if err != nil {
+				fmt.Println("Error:", err)
+				return
+			}
+			secretToUpdate.SetField(field)
+		}
 	}
 
+	secretToUpdate.UpdatedAt = time.Now()
+
 	// Save changes
-	if err := store.SaveStore(filePath, password, s); err != nil {
+	snapshotStore(filePath, "update")
+	if err := store.SaveStoreWithKey(filePath, key, s); err != nil {
 		fmt.Println("Error saving store:", err)
 		return
 	}
@@ -206,11 +271,12 @@ func updateInteractive(s *store.Store, filePath, password string) {
 		}
 	}
 
+	recordChange(filePath, "update")
 	fmt.Println("Secret updated successfully.")
 }
 
 // updateNonInteractive handles the non-interactive update flow
-func updateNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password string) {
+func updateNonInteractive(cmd *cobra.Command, s *store.Store, filePath string, key *store.Key) {
 	name, _ := cmd.Flags().GetString("name")
 	value, _ := cmd.Flags().GetString("value")
 	category, _ := cmd.Flags().GetString("category")
@@ -218,6 +284,26 @@ func updateNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password
 	length, _ := cmd.Flags().GetInt("length")
 	symbols, _ := cmd.Flags().GetBool("symbols")
 	digits, _ := cmd.Flags().GetBool("digits")
+	username, _ := cmd.Flags().GetString("username")
+	urls, _ := cmd.Flags().GetStringSlice("url")
+	notes, _ := cmd.Flags().GetString("notes")
+	fieldSpecs, _ := cmd.Flags().GetStringArray("field")
+	removeFields, _ := cmd.Flags().GetStringArray("remove-field")
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	addTags, _ := cmd.Flags().GetStringSlice("add-tag")
+	removeTags, _ := cmd.Flags().GetStringSlice("remove-tag")
+
+	// Parse custom fields before touching the secret
+	var fields []store.CustomField
+	for _, spec := range fieldSpecs {
+		field, err := store.ParseCustomField(spec)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fields = append(fields, field)
+	}
+
 	// Find the secret to update
 	secretFound := false
 	for i := range s.Secrets {
@@ -225,9 +311,23 @@ func updateNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password
 			secretFound = true
 			secret := &s.Secrets[i]
 
+			if generate && secret.IsTOTP() {
+				fmt.Printf("Error: --generate cannot be used for TOTP secret '%s'; set a new seed with --value\n", name)
+				return
+			}
+
 			// Update value if provided or if generate is true
 			if value != "" {
-				secret.Value = value
+				// TOTP seeds are validated and kept in canonical form
+				if secret.IsTOTP() {
+					seed, err := totp.NormalizeSeed(value)
+					if err != nil {
+						fmt.Println("Error:", err)
+						return
+					}
+					value = seed
+				}
+				secret.SetValue(value)
 			} else if generate {
 				// Generate new password with specified parameters
 				newValue, err := utils.GeneratePassword(length, symbols, digits)
@@ -235,7 +335,7 @@ func updateNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password
 					fmt.Println("Error generating password:", err)
 					return
 				}
-				secret.Value = newValue
+				secret.SetValue(newValue)
 
 				// Copy to clipboard
 				if err := utils.CopyToClipboard(newValue); err != nil {
@@ -247,15 +347,53 @@ func updateNonInteractive(cmd *cobra.Command, s *store.Store, filePath, password
 
 			// Update category if provided and not "-"
 			if category != "-" {
-				secret.Category = category
+				secret.Category = store.NormalizeFolder(category)
+			}
+
+			// Replace, add or remove tags
+			if cmd.Flags().Changed("tag") {
+				secret.Tags = tags
+			}
+			secret.Tags = append(secret.Tags, addTags...)
+			for _, tag := range removeTags {
+				for j, t := range secret.Tags {
+					if t == tag {
+						secret.Tags = append(secret.Tags[:j], secret.Tags[j+1:]...)
+						break
+					}
+				}
+			}
+			secret.Tags = store.NormalizeTags(secret.Tags)
+
+			// Update metadata if provided
+			if username != "-" {
+				secret.Username = username
+			}
+			if cmd.Flags().Changed("url") {
+				secret.URLs = urls
+			}
+			if notes != "-" {
+				secret.Notes = notes
 			}
+			for _, name := range removeFields {
+				if !secret.RemoveField(name) {
+					fmt.Printf("Warning: field '%s' not found\n", name)
+				}
+			}
+			for _, field := range fields {
+				secret.SetField(field)
+			}
+
+			secret.UpdatedAt = time.Now()
 
 			// Save changes
-			if err := store.SaveStore(filePath, password, s); err != nil {
+			snapshotStore(filePath, "update")
+			if err := store.SaveStoreWithKey(filePath, key, s); err != nil {
 				fmt.Println("Error saving store:", err)
 				return
 			}
 
+			recordChange(filePath, "update")
 			fmt.Println("Secret updated successfully.")
 			return
 		}
@@ -275,7 +413,17 @@ func init() {
 	// Core flags
 	updateCmd.Flags().StringP("name", "n", "", "Name of the secret to update (required in non-interactive mode)")
 	updateCmd.Flags().StringP("value", "V", "", "New secret value (or use -g to generate)")
-	updateCmd.Flags().StringP("category", "c", "-", "New category (use \"-\" to keep existing)")
+	updateCmd.Flags().StringP("category", "c", "-", "New category folder, e.g. work/aws/prod (use \"-\" to keep existing)")
+	updateCmd.Flags().StringSlice("tag", nil, "Replace tags (repeatable or comma-separated, pass --tag \"\" to clear)")
+	updateCmd.Flags().StringSlice("add-tag", nil, "Add a tag (repeatable)")
+	updateCmd.Flags().StringSlice("remove-tag", nil, "Remove a tag (repeatable)")
+
+	// Metadata flags
+	updateCmd.Flags().StringP("username", "u", "-", "New username (use \"-\" to keep existing)")
+	updateCmd.Flags().StringSlice("url", nil, "Replace URLs (repeatable, pass --url \"\" to clear)")
+	updateCmd.Flags().String("notes", "-", "New notes (use \"-\" to keep existing)")
+	updateCmd.Flags().StringArrayP("field", "f", nil, "Add or replace a custom field as [type:]name=value (repeatable)")
+	updateCmd.Flags().StringArray("remove-field", nil, "Remove a custom field by name (repeatable)")
 
 	// Password generation flags
 	updateCmd.Flags().BoolP("generate", "g", false, "Generate a random password")
@@ -0,0 +1,176 @@
+// Package agent implements the unlock agent: a background process that keeps
+// derived store keys in memory and hands them to vlxck commands over a Unix
+// socket, so the master password is entered once per session and never
+// written to disk.
+//
+// The socket lives in a directory only the user can access, is created with
+// 0600 permissions, and both ends verify that the peer runs as the same user.
+// Requests and responses are newline-delimited JSON objects.
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// SocketEnv overrides the default socket path.
+const SocketEnv = "VLXCK_AGENT_SOCK"
+
+// dialTimeout bounds how long a command waits for the agent.
+const dialTimeout = 2 * time.Second
+
+// Operations understood by the agent.
+const (
+	opGet    = "get"    // Return the key for a store
+	opSet    = "set"    // Remember the key for a store
+	opForget = "forget" // Drop the key for a store
+	opLock   = "lock"   // Drop all keys
+	opStatus = "status" // Report unlocked stores
+	opStop   = "stop"   // Drop all keys and exit
+)
+
+// request is sent by a client to the agent.
+type request struct {
+	Op    string     `json:"op"`
+	Store string     `json:"store,omitempty"`
+	Key   *store.Key `json:"key,omitempty"`
+}
+
+// response is returned by the agent for every request.
+type response struct {
+	Error  string     `json:"error,omitempty"`
+	Key    *store.Key `json:"key,omitempty"`
+	Status *Status    `json:"status,omitempty"`
+}
+
+// Status describes the state of a running agent.
+type Status struct {
+	// PID is the process ID of the agent
+	PID int `json:"pid"`
+	// Stores lists the store files the agent holds keys for
+	Stores []string `json:"stores"`
+	// IdleTimeout is how long keys are kept without being used
+	IdleTimeout time.Duration `json:"idle_timeout"`
+	// LockIn is the time left until the keys are dropped (0 if locked)
+	LockIn time.Duration `json:"lock_in"`
+}
+
+// SocketPath returns the agent socket path: $VLXCK_AGENT_SOCK if set,
+// otherwise vlxck/agent.sock in $XDG_RUNTIME_DIR or in a per-user
+// directory under the system temporary directory.
+func SocketPath() string {
+	if path := os.Getenv(SocketEnv); path != "" {
+		return path
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "vlxck", "agent.sock")
+	}
+	return filepath.Join(os.TempDir(), "vlxck-"+strconv.Itoa(os.Getuid()), "agent.sock")
+}
+
+// Client talks to a running agent. Each call opens a new connection.
+type Client struct {
+	path string
+}
+
+// NewClient returns a client for the agent listening on the socket path.
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// Running reports whether an agent is listening on the client's socket.
+func (c *Client) Running() bool {
+	_, err := c.call(request{Op: opStatus})
+	return err == nil
+}
+
+// GetKey returns the key the agent holds for the store file.
+//
+// Parameters:
+//   - storePath: Path to the store file
+//
+// Returns:
+//   - *store.Key: The key, or nil if the agent does not hold one
+//   - error: Any error that occurred while talking to the agent
+func (c *Client) GetKey(storePath string) (*store.Key, error) {
+	resp, err := c.call(request{Op: opGet, Store: absPath(storePath)})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Key, nil
+}
+
+// SetKey hands the key for the store file to the agent.
+func (c *Client) SetKey(storePath string, key *store.Key) error {
+	_, err := c.call(request{Op: opSet, Store: absPath(storePath), Key: key})
+	return err
+}
+
+// Forget makes the agent drop the key for the store file.
+func (c *Client) Forget(storePath string) error {
+	_, err := c.call(request{Op: opForget, Store: absPath(storePath)})
+	return err
+}
+
+// Lock makes the agent drop all keys.
+func (c *Client) Lock() error {
+	_, err := c.call(request{Op: opLock})
+	return err
+}
+
+// Stop makes the agent drop all keys and exit.
+func (c *Client) Stop() error {
+	_, err := c.call(request{Op: opStop})
+	return err
+}
+
+// Status returns the state of the agent.
+func (c *Client) Status() (*Status, error) {
+	resp, err := c.call(request{Op: opStatus})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// call sends a request and waits for the response.
+func (c *Client) call(req request) (*response, error) {
+	conn, err := net.DialTimeout("unix", c.path, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("agent is not running: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	// Make sure the agent runs as the same user before handing it keys
+	if err := checkPeer(conn); err != nil {
+		return nil, err
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request to agent: %w", err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response from agent: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("agent: %s", resp.Error)
+	}
+	return &resp, nil
+}
+
+// absPath makes store paths comparable between processes.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,23 @@
+//go:build !linux && !darwin
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// Supported reports whether the agent can run on this platform.
+// Peer credentials cannot be checked here, so the agent is disabled.
+const Supported = false
+
+// checkPeer always fails: peer credentials are not available on this platform.
+func checkPeer(conn net.Conn) error {
+	return fmt.Errorf("the agent is not supported on this platform")
+}
+
+// DetachAttr returns nil: detaching is not supported on this platform.
+func DetachAttr() *syscall.SysProcAttr {
+	return nil
+}
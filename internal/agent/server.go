@@ -0,0 +1,239 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// requestTimeout bounds how long a client may take to send a request.
+const requestTimeout = 5 * time.Second
+
+// Server holds store keys in memory and serves them over a Unix socket.
+// Keys are dropped when they have not been used for the idle timeout.
+type Server struct {
+	idleTimeout time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]*store.Key // Keys by absolute store path
+	idleTimer *time.Timer           // Drops the keys when it fires
+	lockAt    time.Time             // When the idle timer fires
+
+	listener net.Listener
+	path     string
+	done     chan struct{}
+}
+
+// NewServer creates an agent server.
+//
+// Parameters:
+//   - idleTimeout: How long keys are kept without being used
+//
+// Returns:
+//   - *Server: The server, ready to listen
+func NewServer(idleTimeout time.Duration) *Server {
+	return &Server{
+		idleTimeout: idleTimeout,
+		keys:        make(map[string]*store.Key),
+		done:        make(chan struct{}),
+	}
+}
+
+// Listen creates the socket. The parent directory is created with 0700
+// permissions and the socket with 0600. A stale socket left by an agent that
+// exited uncleanly is replaced; a live one is an error.
+//
+// Parameters:
+//   - path: The socket path
+//
+// Returns:
+//   - error: Any error that occurred while creating the socket
+func (s *Server) Listen(path string) error {
+	if !Supported {
+		return fmt.Errorf("the agent is not supported on this platform")
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return fmt.Errorf("failed to secure socket directory: %w", err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if NewClient(path).Running() {
+			return fmt.Errorf("an agent is already running on %s", path)
+		}
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to secure socket: %w", err)
+	}
+
+	s.listener = listener
+	s.path = path
+	return nil
+}
+
+// Serve accepts connections until Close is called or a client requests a stop.
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close drops all keys, stops accepting connections and removes the socket.
+func (s *Server) Close() error {
+	s.lock()
+	select {
+	case <-s.done:
+		return nil
+	default:
+		close(s.done)
+	}
+	err := s.listener.Close()
+	os.Remove(s.path)
+	return err
+}
+
+// handle serves the requests of one connection.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if err := checkPeer(conn); err != nil {
+		json.NewEncoder(conn).Encode(response{Error: err.Error()})
+		return
+	}
+
+	decoder := json.NewDecoder(conn)
+	encoder := json.NewEncoder(conn)
+	for {
+		conn.SetDeadline(time.Now().Add(requestTimeout))
+		var req request
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+		resp := s.dispatch(req)
+		err := encoder.Encode(resp)
+		if resp.Key != nil {
+			resp.Key.Wipe()
+		}
+		if err != nil {
+			return
+		}
+		if req.Op == opStop {
+			go s.Close()
+			return
+		}
+	}
+}
+
+// dispatch executes a request.
+func (s *Server) dispatch(req request) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req.Op {
+	case opGet:
+		key, ok := s.keys[req.Store]
+		if !ok {
+			return response{}
+		}
+		s.touch()
+		// Copy under the lock: the stored key may be wiped by a concurrent
+		// lock or set while the response is encoded
+		return response{Key: key.Clone()}
+	case opSet:
+		if req.Store == "" || req.Key == nil || len(req.Key.Bytes) == 0 {
+			return response{Error: "store and key are required"}
+		}
+		if old, ok := s.keys[req.Store]; ok {
+			old.Wipe()
+		}
+		s.keys[req.Store] = req.Key
+		s.touch()
+		return response{}
+	case opForget:
+		if key, ok := s.keys[req.Store]; ok {
+			key.Wipe()
+			delete(s.keys, req.Store)
+		}
+		return response{}
+	case opLock, opStop:
+		s.wipe()
+		return response{}
+	case opStatus:
+		return response{Status: s.status()}
+	default:
+		return response{Error: fmt.Sprintf("unknown operation '%s'", req.Op)}
+	}
+}
+
+// touch restarts the idle timer. The caller must hold s.mu.
+func (s *Server) touch() {
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+	s.lockAt = time.Now().Add(s.idleTimeout)
+	s.idleTimer = time.AfterFunc(s.idleTimeout, s.lock)
+}
+
+// lock drops all keys.
+func (s *Server) lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.wipe()
+}
+
+// wipe overwrites and drops all keys. The caller must hold s.mu.
+func (s *Server) wipe() {
+	for path, key := range s.keys {
+		key.Wipe()
+		delete(s.keys, path)
+	}
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+	}
+	s.lockAt = time.Time{}
+}
+
+// status describes the agent. The caller must hold s.mu.
+func (s *Server) status() *Status {
+	status := &Status{PID: os.Getpid(), Stores: []string{}, IdleTimeout: s.idleTimeout}
+	for path := range s.keys {
+		status.Stores = append(status.Stores, path)
+	}
+	sort.Strings(status.Stores)
+	if !s.lockAt.IsZero() {
+		status.LockIn = time.Until(s.lockAt).Round(time.Second)
+	}
+	return status
+}
@@ -0,0 +1,182 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// manifestName is the archive entry holding the manifest of a backup.
+const manifestName = "manifest.json"
+
+// zipMagic starts every zip archive, i.e. every plaintext backup.
+var zipMagic = []byte("PK\x03\x04")
+
+// Manifest lists the files of a backup with their SHA-256 checksums. It is
+// written into the archive at backup time and checked when the backup is opened.
+type Manifest struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Files     map[string]string `json:"files"` // Archive path -> SHA-256 checksum
+}
+
+// write adds the manifest to the archive.
+func (m Manifest) write(zipWriter *zip.Writer) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	writer, err := zipWriter.Create(manifestName)
+	if err != nil {
+		return fmt.Errorf("failed to create zip entry for %s: %w", manifestName, err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to zip: %w", manifestName, err)
+	}
+	return nil
+}
+
+// Archive is the decrypted and verified content of a backup.
+type Archive struct {
+	// Files maps archive paths to file contents; the manifest is not included
+	Files map[string][]byte
+	// Verified is true if every file matched the manifest, false if the
+	// backup has no manifest (plaintext backups written by older versions)
+	Verified bool
+}
+
+// Names returns the archive paths of the files, sorted.
+func (a *Archive) Names() []string {
+	names := make([]string, 0, len(a.Files))
+	for name := range a.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open reads, decrypts and verifies a backup without writing anything.
+// Encrypted backups are decrypted with the key returned by keyFor for the
+// backup's header; plaintext zip backups written by older versions are read
+// as they are. Every path in the archive must stay inside the directory it is
+// extracted to, and every file must match the checksum in the manifest.
+//
+// Parameters:
+//   - backupFile: The backup file to open
+//   - keyFor: Returns the key to decrypt an encrypted backup with
+//
+// Returns:
+//   - *Archive: The files of the backup
+//   - error: Any error that occurred while decrypting, reading or verifying the backup
+func Open(backupFile string, keyFor func(*store.Header) (*store.Key, error)) (*Archive, error) {
+	data, err := os.ReadFile(backupFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("backup file is empty")
+	}
+
+	switch {
+	case bytes.HasPrefix(data, zipMagic):
+	case store.IsSealed(data):
+		data, err = store.Open(data, keyFor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt backup (wrong password or passphrase?): %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("not a valid backup file (invalid header: %x)", data[:min(len(data), 4)])
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip reader: %w", err)
+	}
+
+	archive := &Archive{Files: make(map[string][]byte)}
+	var manifest *Manifest
+	for _, file := range reader.File {
+		if !filepath.IsLocal(filepath.FromSlash(file.Name)) {
+			return nil, fmt.Errorf("backup contains an unsafe path: %q", file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			return nil, fmt.Errorf("backup contains a file that is not a regular file: %q", file.Name)
+		}
+		if _, ok := archive.Files[file.Name]; ok {
+			return nil, fmt.Errorf("backup contains %q more than once", file.Name)
+		}
+
+		content, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if file.Name == manifestName {
+			manifest = &Manifest{}
+			if err := json.Unmarshal(content, manifest); err != nil {
+				return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+			}
+			continue
+		}
+		archive.Files[file.Name] = content
+	}
+
+	if len(archive.Files) == 0 {
+		return nil, fmt.Errorf("backup file is empty or corrupted (no files found in archive)")
+	}
+	if manifest != nil {
+		if err := manifest.verify(archive.Files); err != nil {
+			return nil, err
+		}
+		archive.Verified = true
+	}
+	return archive, nil
+}
+
+// readFile reads a file of the archive.
+func readFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s in backup: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s in backup: %w", file.Name, err)
+	}
+	return content, nil
+}
+
+// verify checks that the files are exactly those of the manifest, with
+// matching checksums.
+func (m *Manifest) verify(files map[string][]byte) error {
+	for name, content := range files {
+		expected, ok := m.Files[name]
+		if !ok {
+			return fmt.Errorf("backup verification failed: %s is not in the manifest", name)
+		}
+		checksum, err := Checksum(bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		if checksum != expected {
+			return fmt.Errorf("backup verification failed: checksum mismatch for %s", name)
+		}
+	}
+	for name := range m.Files {
+		if _, ok := files[name]; !ok {
+			return fmt.Errorf("backup verification failed: %s is missing", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package backup
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/crypto"
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// testKDFParams keep key derivation cheap in tests.
+var testKDFParams = crypto.KDFParams{Time: 1, Memory: 64, Threads: 1}
+
+// zipEntry is a file of an archive built by writeZip.
+type zipEntry struct {
+	name    string
+	content string
+	mode    os.FileMode // Regular file if zero
+}
+
+// writeZip writes a plaintext backup holding the entries and returns its path.
+func writeZip(t *testing.T, entries ...zipEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "backup_20250620-183238.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zipWriter := zip.NewWriter(file)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		if entry.mode != 0 {
+			header.SetMode(entry.mode)
+		}
+		writer, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// manifestOf returns the manifest entry listing the files with their checksums.
+func manifestOf(t *testing.T, files map[string]string) zipEntry {
+	t.Helper()
+	manifest := Manifest{CreatedAt: time.Now(), Files: make(map[string]string)}
+	for name, content := range files {
+		checksum, err := Checksum(strings.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifest.Files[name] = checksum
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zipEntry{name: manifestName, content: string(data)}
+}
+
+func TestOpenRejectsUnsafeArchives(t *testing.T) {
+	storeFile := zipEntry{name: StoreFile, content: "store"}
+	tests := []struct {
+		name    string
+		entries func(t *testing.T) []zipEntry
+		wantErr string
+	}{
+		{
+			name: "parent directory",
+			entries: func(t *testing.T) []zipEntry {
+				return []zipEntry{{name: "../x", content: "x"}, storeFile}
+			},
+			wantErr: "unsafe path",
+		},
+		{
+			name: "nested parent directory",
+			entries: func(t *testing.T) []zipEntry {
+				return []zipEntry{{name: "snapshots/../../x", content: "x"}, storeFile}
+			},
+			wantErr: "unsafe path",
+		},
+		{
+			name: "absolute path",
+			entries: func(t *testing.T) []zipEntry {
+				return []zipEntry{{name: "/etc/x", content: "x"}, storeFile}
+			},
+			wantErr: "unsafe path",
+		},
+		{
+			name: "symbolic link",
+			entries: func(t *testing.T) []zipEntry {
+				return []zipEntry{{name: StoreFile, content: "/etc/passwd", mode: os.ModeSymlink | 0777}}
+			},
+			wantErr: "not a regular file",
+		},
+		{
+			name: "duplicate entry",
+			entries: func(t *testing.T) []zipEntry {
+				return []zipEntry{storeFile, {name: StoreFile, content: "other"}}
+			},
+			wantErr: "more than once",
+		},
+		{
+			name: "tampered checksum",
+			entries: func(t *testing.T) []zipEntry {
+				return []zipEntry{manifestOf(t, map[string]string{StoreFile: "original"}), storeFile}
+			},
+			wantErr: "checksum mismatch for store.dat",
+		},
+		{
+			name: "file not in the manifest",
+			entries: func(t *testing.T) []zipEntry {
+				return []zipEntry{
+					manifestOf(t, map[string]string{StoreFile: "store"}),
+					storeFile,
+					{name: ConfigFile, content: "sync: {}"},
+				}
+			},
+			wantErr: "config.yaml is not in the manifest",
+		},
+		{
+			name: "file missing from the archive",
+			entries: func(t *testing.T) []zipEntry {
+				return []zipEntry{
+					manifestOf(t, map[string]string{StoreFile: "store", ConfigFile: "sync: {}"}),
+					storeFile,
+				}
+			},
+			wantErr: "config.yaml is missing",
+		},
+		{
+			name: "no files",
+			entries: func(t *testing.T) []zipEntry {
+				return []zipEntry{manifestOf(t, nil)}
+			},
+			wantErr: "no files found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeZip(t, tt.entries(t)...)
+			archive, err := Open(path, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Open: archive = %v, err = %v, want error containing %q", archive, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenVerifiesManifest(t *testing.T) {
+	files := map[string]string{StoreFile: "store", ConfigFile: "sync: {}"}
+	path := writeZip(t,
+		manifestOf(t, files),
+		zipEntry{name: StoreFile, content: files[StoreFile]},
+		zipEntry{name: ConfigFile, content: files[ConfigFile]},
+	)
+
+	archive, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !archive.Verified {
+		t.Error("archive with a matching manifest is not verified")
+	}
+	if got := strings.Join(archive.Names(), ","); got != "config.yaml,store.dat" {
+		t.Errorf("Names = %s, want the files without the manifest", got)
+	}
+}
+
+func TestOpenLegacyWithoutManifest(t *testing.T) {
+	path := writeZip(t, zipEntry{name: StoreFile, content: "store"})
+
+	archive, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if archive.Verified {
+		t.Error("archive without a manifest is reported as verified")
+	}
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	sourceDir := t.TempDir()
+	files := map[string]string{StoreFile: "store", ConfigFile: "sync: {}", "key.cache": "not backed up"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(sourceDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	header, err := store.NewHeader(testKDFParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := header.Key("master")
+
+	backupFile, err := Backup(sourceDir, t.TempDir(), key)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+
+	wrongKey := func(h *store.Header) (*store.Key, error) { return h.Key("wrong"), nil }
+	if _, err := Open(backupFile, wrongKey); err == nil {
+		t.Error("Open with the wrong password succeeded")
+	}
+
+	keyFor := func(*store.Header) (*store.Key, error) { return key, nil }
+	targetDir := t.TempDir()
+	if err := Restore(backupFile, targetDir, []string{StoreFile}, keyFor); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(targetDir, StoreFile))
+	if err != nil || string(data) != files[StoreFile] {
+		t.Errorf("restored store = %q, %v", data, err)
+	}
+	for _, name := range []string{ConfigFile, "key.cache"} {
+		if _, err := os.Stat(filepath.Join(targetDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was restored without being asked for", name)
+		}
+	}
+
+	if err := Restore(backupFile, targetDir, []string{StoreFile, "key.cache"}, keyFor); err == nil {
+		t.Error("Restore of a file the backup does not contain succeeded")
+	}
+}
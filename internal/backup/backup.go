@@ -2,6 +2,7 @@ package backup
 
 import (
 	"archive/zip"
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -9,11 +10,43 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// Files of the store directory included in a backup.
+const (
+	StoreFile  = "store.dat"
+	ConfigFile = "config.yaml"
+)
+
+// Files are the files of the store directory included in a backup. Backups,
+// the git sync repository and other working files are left out.
+var Files = []string{StoreFile, ConfigFile}
+
+const (
+	backupPrefix    = "backup_"         // File name prefix of all backups
+	encryptedExt    = ".enc"            // Extension of encrypted backups
+	legacyExt       = ".zip"            // Extension of plaintext backups written by older versions
+	timestampLayout = "20060102-150405" // Timestamp in backup file names
 )
 
-// Backup creates a zip archive of the source directory and saves it to the backup directory
-func Backup(sourceDir, backupDir string) (string, error) {
+// Backup creates an encrypted zip archive of the store files in the source
+// directory and saves it to the backup directory. The archive is sealed with
+// the key like a store file, so it opens with the password the key was
+// derived from: the master password or a separate backup passphrase.
+//
+// Parameters:
+//   - sourceDir: The store directory
+//   - backupDir: The directory to save the backup to
+//   - key: The key to encrypt the backup with
+//
+// Returns:
+//   - string: The path of the backup file
+//   - error: Any error that occurred while creating the backup
+func Backup(sourceDir, backupDir string, key *store.Key) (string, error) {
 	sourceInfo, err := os.Stat(sourceDir)
 	if err != nil {
 		return "", fmt.Errorf("source directory not found: %w", err)
@@ -22,187 +55,200 @@ func Backup(sourceDir, backupDir string) (string, error) {
 		return "", fmt.Errorf("source path is not a directory: %s", sourceDir)
 	}
 
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create backup directory: %w", err)
-	}
-
-	timestamp := time.Now().Format("20060102-150405")
-	backupFile := filepath.Join(backupDir, fmt.Sprintf("backup_%s.zip", timestamp))
-
-	zipFile, err := os.Create(backupFile)
-	if err != nil {
-		return "", fmt.Errorf("failed to create backup file: %w", err)
+	if _, err := os.Stat(filepath.Join(sourceDir, StoreFile)); err != nil {
+		return "", fmt.Errorf("store file not found: %w", err)
 	}
 
-	zipWriter := zip.NewWriter(zipFile)
-
-	err = filepath.Walk(sourceDir, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		if filePath == backupFile {
-			return nil
-		}
-		relPath, err := filepath.Rel(sourceDir, filePath)
-		if err != nil {
-			return fmt.Errorf("failed to get relative path for %s: %w", filePath, err)
-		}
-
-		header, err := zip.FileInfoHeader(info)
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	manifest := Manifest{CreatedAt: time.Now(), Files: make(map[string]string)}
+	for _, name := range Files {
+		checksum, err := addFile(zipWriter, sourceDir, name)
 		if err != nil {
-			return fmt.Errorf("failed to create zip header for %s: %w", filePath, err)
+			return "", err
 		}
-
-		header.Name = filepath.ToSlash(relPath)
-		header.Method = zip.Deflate
-
-		writer, err := zipWriter.CreateHeader(header)
-		if err != nil {
-			return fmt.Errorf("failed to create zip entry for %s: %w", relPath, err)
+		if checksum != "" {
+			manifest.Files[name] = checksum
 		}
+	}
+	if err := manifest.write(zipWriter); err != nil {
+		return "", err
+	}
+	if err := zipWriter.Close(); err != nil {
+		return "", fmt.Errorf("failed to close zip writer: %w", err)
+	}
 
-		file, err := os.Open(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to open source file %s: %w", filePath, err)
-		}
-		defer file.Close()
+	sealed, err := store.Seal(key, archive.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt backup: %w", err)
+	}
 
-		_, err = io.Copy(writer, file)
-		if err != nil {
-			return fmt.Errorf("failed to write file %s to zip: %w", filePath, err)
-		}
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
 
-		return nil
-	})
+	timestamp := time.Now().Format(timestampLayout)
+	backupFile := filepath.Join(backupDir, backupPrefix+timestamp+encryptedExt)
 
+	// Never overwrite an existing backup, e.g. one created in the same second
+	file, err := os.OpenFile(backupFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
 	if err != nil {
-		zipWriter.Close()
-		zipFile.Close()
-		os.Remove(backupFile)
-		return "", fmt.Errorf("error walking source directory: %w", err)
+		return "", fmt.Errorf("failed to create backup file: %w", err)
 	}
-
-	if err := zipWriter.Close(); err != nil {
-		zipFile.Close()
+	if _, err := file.Write(sealed); err != nil {
+		file.Close()
 		os.Remove(backupFile)
-		return "", fmt.Errorf("failed to close zip writer: %w", err)
+		return "", fmt.Errorf("failed to write backup file: %w", err)
 	}
-
-	if err := zipFile.Close(); err != nil {
+	if err := file.Close(); err != nil {
 		os.Remove(backupFile)
 		return "", fmt.Errorf("failed to close backup file: %w", err)
 	}
 
-	if info, err := os.Stat(backupFile); err != nil || info.Size() == 0 {
-		if err == nil {
-			os.Remove(backupFile)
-		}
-		return "", fmt.Errorf("backup file was not created successfully")
-	}
-
 	return backupFile, nil
 }
 
-// Restore extracts a backup zip file to the target directory
-func Restore(backupFile, targetDir string) error {
-	fileInfo, err := os.Stat(backupFile)
+// addFile adds a file of the source directory to the archive and returns its
+// SHA-256 checksum. Missing files are skipped with an empty checksum, e.g.
+// config.yaml before anything was configured.
+func addFile(zipWriter *zip.Writer, sourceDir, name string) (string, error) {
+	filePath := filepath.Join(sourceDir, name)
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
 	if err != nil {
-		return fmt.Errorf("backup file not found: %w", err)
+		return "", fmt.Errorf("failed to stat %s: %w", filePath, err)
 	}
 
-	if fileInfo.Size() == 0 {
-		return fmt.Errorf("backup file is empty")
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return "", fmt.Errorf("failed to create zip header for %s: %w", filePath, err)
 	}
+	header.Name = name
+	header.Method = zip.Deflate
 
-	fmt.Printf("Attempting to restore backup: %s (Size: %d bytes)\n", backupFile, fileInfo.Size())
+	writer, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to create zip entry for %s: %w", name, err)
+	}
 
-	file, err := os.Open(backupFile)
+	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to open backup file for reading: %w", err)
+		return "", fmt.Errorf("failed to open source file %s: %w", filePath, err)
 	}
 	defer file.Close()
 
-	header := make([]byte, 4)
-	if _, err := file.Read(header); err != nil {
-		return fmt.Errorf("failed to read file header: %w", err)
+	checksum, err := Checksum(io.TeeReader(file, writer))
+	if err != nil {
+		return "", fmt.Errorf("failed to write file %s to zip: %w", filePath, err)
 	}
+	return checksum, nil
+}
 
-	if string(header) != "PK\x03\x04" {
-		return fmt.Errorf("not a valid zip file (invalid header: %x)", header)
+// Restore extracts files of a backup to the target directory. The backup is
+// opened with Open, so nothing is written unless every file passes
+// verification; each file is then written next to its destination and
+// renamed over it. Files of the backup that are not named are left out.
+//
+// Parameters:
+//   - backupFile: The backup file to restore
+//   - targetDir: The directory to extract the backup to
+//   - names: The archive paths of the files to restore, e.g. StoreFile
+//   - keyFor: Returns the key to decrypt an encrypted backup with
+//
+// Returns:
+//   - error: Any error that occurred while opening, verifying or extracting
+//     the backup, or if a named file is missing from it
+func Restore(backupFile, targetDir string, names []string, keyFor func(*store.Header) (*store.Key, error)) error {
+	fileInfo, err := os.Stat(backupFile)
+	if err != nil {
+		return fmt.Errorf("backup file not found: %w", err)
 	}
 
-	reader, err := zip.OpenReader(backupFile)
+	fmt.Printf("Attempting to restore backup: %s (Size: %d bytes)\n", backupFile, fileInfo.Size())
+
+	archive, err := Open(backupFile, keyFor)
 	if err != nil {
-		return fmt.Errorf("failed to open zip reader: %w", err)
+		return err
 	}
-	defer reader.Close()
 
-	fmt.Printf("Found %d files in backup\n", len(reader.File))
+	fmt.Printf("Found %d files in backup\n", len(archive.Files))
+	if archive.Verified {
+		fmt.Println("Verified all files against the backup manifest")
+	} else {
+		fmt.Println("Warning: The backup has no manifest (created by an older version); its files cannot be verified")
+	}
 
-	if len(reader.File) == 0 {
-		return fmt.Errorf("backup file is empty or corrupted (no files found in archive)")
+	for _, name := range names {
+		if _, ok := archive.Files[name]; !ok {
+			return fmt.Errorf("backup does not contain %s", name)
+		}
 	}
 
 	if err := os.MkdirAll(targetDir, 0755); err != nil {
 		return fmt.Errorf("failed to create target directory: %w", err)
 	}
 
-	for _, file := range reader.File {
-		extractPath := filepath.Join(targetDir, file.Name)
-
-		if file.FileInfo().IsDir() {
-			if err := os.MkdirAll(extractPath, file.Mode()); err != nil {
-				return fmt.Errorf("failed to create directory: %w", err)
-			}
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(extractPath), 0755); err != nil {
-			return fmt.Errorf("failed to create parent directory: %w", err)
-		}
-
-		fileWriter, err := os.OpenFile(extractPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
-		if err != nil {
-			return fmt.Errorf("failed to create file: %w", err)
-		}
-
-		fileReader, err := file.Open()
-		if err != nil {
-			fileWriter.Close()
-			return fmt.Errorf("failed to open file in backup: %w", err)
+	for _, name := range names {
+		if err := extractFile(targetDir, name, archive.Files[name]); err != nil {
+			return err
 		}
+	}
 
-		if _, err := io.Copy(fileWriter, fileReader); err != nil {
-			fileWriter.Close()
-			fileReader.Close()
-			return fmt.Errorf("failed to extract file: %w", err)
-		}
+	return nil
+}
 
-		fileWriter.Close()
-		fileReader.Close()
+// extractFile writes a file of the archive below the target directory. It is
+// written to a temporary file first, so that a failed write leaves the
+// existing file intact.
+func extractFile(targetDir, name string, data []byte) error {
+	extractPath := filepath.Join(targetDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(extractPath), 0700); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
 	}
 
+	tmpPath := extractPath + ".restore"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to extract file %s: %w", name, err)
+	}
+	if err := os.Rename(tmpPath, extractPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to extract file %s: %w", name, err)
+	}
 	return nil
 }
 
 // BackupInfo contains information about a backup file
 type BackupInfo struct {
-	Path    string //
-	Name    string
-	Size    int64
-	ModTime time.Time
+	Path      string
+	Name      string
+	Size      int64
+	ModTime   time.Time
+	Encrypted bool // False for plaintext backups written by older versions
+}
+
+// CreatedAt returns when the backup was created: the timestamp in its file
+// name, or its modification time if the name has none.
+func (b BackupInfo) CreatedAt() time.Time {
+	name := strings.TrimPrefix(b.Name, backupPrefix)
+	name = strings.TrimSuffix(strings.TrimSuffix(name, encryptedExt), legacyExt)
+	if t, err := time.ParseInLocation(timestampLayout, name, time.Local); err == nil {
+		return t
+	}
+	return b.ModTime
 }
 
-// ListBackups returns a list of all backup files in the backup directory with their metadata
+// ListBackups returns a list of all backup files in the backup directory with
+// their metadata, newest first
 func ListBackups(backupDir string) ([]BackupInfo, error) {
-	files, err := filepath.Glob(filepath.Join(backupDir, "backup_*.zip"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to list backup files: %w", err)
+	var files []string
+	for _, ext := range []string{encryptedExt, legacyExt} {
+		matches, err := filepath.Glob(filepath.Join(backupDir, backupPrefix+"*"+ext))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list backup files: %w", err)
+		}
+		files = append(files, matches...)
 	}
 
 	var backups []BackupInfo
@@ -213,15 +259,16 @@ func ListBackups(backupDir string) ([]BackupInfo, error) {
 		}
 
 		backups = append(backups, BackupInfo{
-			Path:    file,
-			Name:    filepath.Base(file),
-			Size:    info.Size(),
-			ModTime: info.ModTime(),
+			Path:      file,
+			Name:      filepath.Base(file),
+			Size:      info.Size(),
+			ModTime:   info.ModTime(),
+			Encrypted: strings.HasSuffix(file, encryptedExt),
 		})
 	}
 
 	sort.Slice(backups, func(i, j int) bool {
-		return backups[i].ModTime.After(backups[j].ModTime)
+		return backups[i].CreatedAt().After(backups[j].CreatedAt())
 	})
 
 	return backups, nil
@@ -235,8 +282,13 @@ func GetFileChecksum(filePath string) (string, error) {
 	}
 	defer file.Close()
 
+	return Checksum(file)
+}
+
+// Checksum calculates the SHA-256 checksum of everything read from the reader
+func Checksum(r io.Reader) (string, error) {
 	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
+	if _, err := io.Copy(hash, r); err != nil {
 		return "", err
 	}
 
@@ -0,0 +1,112 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Policy decides which backups to keep when pruning. A backup is kept if any
+// rule keeps it: it is one of the newest KeepLast backups, or the newest
+// backup of one of the newest KeepDaily days, KeepWeekly ISO weeks or
+// KeepMonthly months that have backups.
+type Policy struct {
+	KeepLast    int // Number of newest backups to keep
+	KeepDaily   int // Number of days to keep the newest backup of
+	KeepWeekly  int // Number of weeks to keep the newest backup of
+	KeepMonthly int // Number of months to keep the newest backup of
+}
+
+// IsEmpty reports whether the policy keeps no backups at all.
+func (p Policy) IsEmpty() bool {
+	return p.KeepLast <= 0 && p.KeepDaily <= 0 && p.KeepWeekly <= 0 && p.KeepMonthly <= 0
+}
+
+// Apply splits the backups into those the policy keeps and those it removes.
+// Both lists are sorted newest first.
+//
+// Parameters:
+//   - backups: The backups to apply the policy to
+//
+// Returns:
+//   - []BackupInfo: The backups to keep
+//   - []BackupInfo: The backups to remove
+func (p Policy) Apply(backups []BackupInfo) ([]BackupInfo, []BackupInfo) {
+	sorted := append([]BackupInfo(nil), backups...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt().After(sorted[j].CreatedAt())
+	})
+
+	keep := make([]bool, len(sorted))
+	for i := 0; i < len(sorted) && i < p.KeepLast; i++ {
+		keep[i] = true
+	}
+	keepBuckets(sorted, keep, p.KeepDaily, func(t time.Time) string {
+		return t.Format("2006-01-02")
+	})
+	keepBuckets(sorted, keep, p.KeepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+	keepBuckets(sorted, keep, p.KeepMonthly, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+
+	var kept, removed []BackupInfo
+	for i, b := range sorted {
+		if keep[i] {
+			kept = append(kept, b)
+		} else {
+			removed = append(removed, b)
+		}
+	}
+	return kept, removed
+}
+
+// keepBuckets marks the newest backup of each of the n newest buckets as
+// kept. The backups must be sorted newest first.
+func keepBuckets(backups []BackupInfo, keep []bool, n int, bucket func(time.Time) string) {
+	seen := make(map[string]bool)
+	for i, b := range backups {
+		if len(seen) >= n {
+			return
+		}
+		key := bucket(b.CreatedAt().Local())
+		if !seen[key] {
+			seen[key] = true
+			keep[i] = true
+		}
+	}
+}
+
+// Prune deletes the backups in the backup directory that the policy does not
+// keep. A policy that keeps nothing is refused rather than deleting every backup.
+//
+// Parameters:
+//   - backupDir: The directory containing the backups
+//   - policy: The retention policy
+//
+// Returns:
+//   - []BackupInfo: The deleted backups
+//   - error: Any error that occurred while listing or deleting the backups
+func Prune(backupDir string, policy Policy) ([]BackupInfo, error) {
+	if policy.IsEmpty() {
+		return nil, fmt.Errorf("the retention policy keeps no backups")
+	}
+
+	backups, err := ListBackups(backupDir)
+	if err != nil {
+		return nil, err
+	}
+
+	_, remove := policy.Apply(backups)
+	var removed []BackupInfo
+	for _, b := range remove {
+		if err := os.Remove(b.Path); err != nil {
+			return removed, fmt.Errorf("failed to delete backup %s: %w", b.Name, err)
+		}
+		removed = append(removed, b)
+	}
+	return removed, nil
+}
@@ -0,0 +1,132 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testBackups are backups taken over three months, oldest last. June 16 to
+// 20, 2025 is ISO week 25, June 10 week 24 and May 31 week 22.
+var testBackups = []string{
+	"2025-06-20 18:00",
+	"2025-06-20 09:00",
+	"2025-06-19 12:00",
+	"2025-06-16 12:00",
+	"2025-06-10 12:00",
+	"2025-05-31 12:00",
+	"2025-04-15 12:00",
+}
+
+// backupInfo returns a backup taken at the time, given as in testBackups.
+func backupInfo(t *testing.T, at string) BackupInfo {
+	t.Helper()
+	createdAt, err := time.ParseInLocation("2006-01-02 15:04", at, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return BackupInfo{Name: backupPrefix + createdAt.Format(timestampLayout) + encryptedExt}
+}
+
+// backupTimes returns the times of the backups as in testBackups.
+func backupTimes(backups []BackupInfo) []string {
+	times := []string{}
+	for _, b := range backups {
+		times = append(times, b.CreatedAt().Format("2006-01-02 15:04"))
+	}
+	return times
+}
+
+func TestPolicyApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		kept   []string
+	}{
+		{
+			name:   "last",
+			policy: Policy{KeepLast: 2},
+			kept:   []string{"2025-06-20 18:00", "2025-06-20 09:00"},
+		},
+		{
+			name:   "daily",
+			policy: Policy{KeepDaily: 2},
+			kept:   []string{"2025-06-20 18:00", "2025-06-19 12:00"},
+		},
+		{
+			name:   "weekly",
+			policy: Policy{KeepWeekly: 3},
+			kept:   []string{"2025-06-20 18:00", "2025-06-10 12:00", "2025-05-31 12:00"},
+		},
+		{
+			name:   "monthly",
+			policy: Policy{KeepMonthly: 3},
+			kept:   []string{"2025-06-20 18:00", "2025-05-31 12:00", "2025-04-15 12:00"},
+		},
+		{
+			name:   "combined",
+			policy: Policy{KeepLast: 1, KeepDaily: 2, KeepWeekly: 2, KeepMonthly: 3},
+			kept:   []string{"2025-06-20 18:00", "2025-06-19 12:00", "2025-06-10 12:00", "2025-05-31 12:00", "2025-04-15 12:00"},
+		},
+		{
+			name:   "more than there are",
+			policy: Policy{KeepDaily: 30},
+			kept:   []string{"2025-06-20 18:00", "2025-06-19 12:00", "2025-06-16 12:00", "2025-06-10 12:00", "2025-05-31 12:00", "2025-04-15 12:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Apply sorts the backups itself
+			var backups []BackupInfo
+			for i := len(testBackups) - 1; i >= 0; i-- {
+				backups = append(backups, backupInfo(t, testBackups[i]))
+			}
+
+			kept, removed := tt.policy.Apply(backups)
+			if got := backupTimes(kept); !reflect.DeepEqual(got, tt.kept) {
+				t.Errorf("kept = %v, want %v", got, tt.kept)
+			}
+			if len(kept)+len(removed) != len(testBackups) {
+				t.Errorf("kept %d and removed %d of %d backups", len(kept), len(removed), len(testBackups))
+			}
+			for _, b := range removed {
+				for _, k := range kept {
+					if b.Name == k.Name {
+						t.Errorf("%s is both kept and removed", b.Name)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	backupDir := t.TempDir()
+	for _, at := range testBackups {
+		if err := os.WriteFile(filepath.Join(backupDir, backupInfo(t, at).Name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := Prune(backupDir, Policy{}); err == nil {
+		t.Fatal("Prune with an empty policy succeeded")
+	}
+
+	removed, err := Prune(backupDir, Policy{KeepLast: 1, KeepDaily: 2})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if len(removed) != 5 {
+		t.Errorf("removed %d backups, want 5", len(removed))
+	}
+	left, err := ListBackups(backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := backupTimes(left), []string{"2025-06-20 18:00", "2025-06-19 12:00"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("left = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,252 @@
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// wrappingKeySize is the size of the random AES-256 wrapping key.
+const wrappingKeySize = 32
+
+var (
+	cacheFile      string // Path to the cache file (~/.config/vlxck/key.cache)
+	legacyFile     string // Path to the password cache of older versions
+	wrappingKeyDir string // Directory holding the wrapping key
+)
+
+func init() {
+	// Initialize cache file path
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		// Fallback to temp directory (/tmp on Linux) if unavailable
+		configDir = os.TempDir()
+	}
+	cacheDir := filepath.Join(configDir, "vlxck")
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		// If creating the directory fails, fall back to the temp directory
+		cacheDir = os.TempDir()
+	}
+	cacheFile = filepath.Join(cacheDir, "key.cache")
+	legacyFile = filepath.Join(cacheDir, "password.cache")
+
+	// The wrapping key lives in the session's runtime directory when there is one
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		wrappingKeyDir = filepath.Join(dir, "vlxck")
+	} else {
+		wrappingKeyDir = filepath.Join(os.TempDir(), "vlxck-"+strconv.Itoa(os.Getuid()))
+	}
+}
+
+// wrappingKeyFile returns the path of the wrapping key.
+func wrappingKeyFile() string {
+	return filepath.Join(wrappingKeyDir, "cache.key")
+}
+
+// fileBackend keeps the key in an encrypted file in the user's config
+// directory, with the wrapping key stored in the runtime directory.
+type fileBackend struct{}
+
+// Name returns the name of the backend.
+func (fileBackend) Name() string {
+	return BackendFile
+}
+
+// Set caches the store key for the specified timeout under a newly
+// generated wrapping key. If timeout is less than or equal to 0, the cache
+// is cleared.
+//
+// Parameters:
+//   - storePath: Path to the store file the key belongs to
+//   - key: The derived store key
+//   - timeout: The duration for which the key should be cached
+//
+// Returns:
+//   - error: Any error that occurred during caching
+func (b fileBackend) Set(storePath string, key *store.Key, timeout time.Duration) error {
+	if timeout <= 0 {
+		return b.Clear()
+	}
+	// Older versions cached the password itself; make sure it is gone
+	os.Remove(legacyFile)
+
+	data := cacheData{
+		Store:     absPath(storePath),
+		Key:       key,
+		ExpiresAt: time.Now().Add(timeout),
+	}
+
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache data: %w", err)
+	}
+	defer wipe(plaintext)
+
+	// Generate a fresh wrapping key, so that a cache file left over from an
+	// earlier session can never be decrypted again
+	wrappingKey := make([]byte, wrappingKeySize)
+	if _, err := io.ReadFull(rand.Reader, wrappingKey); err != nil {
+		return fmt.Errorf("failed to generate wrapping key: %w", err)
+	}
+	defer wipe(wrappingKey)
+
+	gcm, err := newGCM(wrappingKey)
+	if err != nil {
+		return err
+	}
+
+	// Generate a nonce (number used once) — a unique random number for each encryption.
+	// Nonce ensures that identical data encrypts differently each time, enhancing security
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// Encrypt the data. Seal prepends the nonce to the ciphertext.
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+
+	if err := os.MkdirAll(wrappingKeyDir, 0700); err != nil {
+		return fmt.Errorf("failed to create wrapping key directory: %w", err)
+	}
+	if err := os.Chmod(wrappingKeyDir, 0700); err != nil {
+		return fmt.Errorf("failed to secure wrapping key directory: %w", err)
+	}
+	if err := writeFile(wrappingKeyFile(), wrappingKey); err != nil {
+		return fmt.Errorf("failed to write wrapping key: %w", err)
+	}
+	if err := writeFile(cacheFile, []byte(base64.StdEncoding.EncodeToString(ciphertext))); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves the cached key for the store if it exists and is not expired.
+//
+// Parameters:
+//   - storePath: Path to the store file
+//
+// Returns:
+//   - *store.Key: The cached key, or nil if no usable key is cached
+//   - error: Any error that occurred during retrieval
+func (b fileBackend) Get(storePath string) (*store.Key, error) {
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // If the file doesn't exist, there is no cached key
+		}
+		return nil, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	wrappingKey, err := os.ReadFile(wrappingKeyFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The session ended (e.g. logout or reboot); the cache can't be decrypted
+			b.Clear()
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read wrapping key: %w", err)
+	}
+	defer wipe(wrappingKey)
+
+	// Decode the data from Base64 back to binary format
+	ciphertext, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cache data: %w", err)
+	}
+
+	gcm, err := newGCM(wrappingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract nonce and encrypted data from ciphertext
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	// Split nonce and ciphertext
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+
+	// Decrypt the data.
+	// Open verifies integrity and decrypts the data.
+	// If the data was tampered with or the key doesn't match, it returns an error
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt cache: %w", err)
+	}
+	defer wipe(plaintext)
+
+	var cacheData cacheData
+	if err := json.Unmarshal(plaintext, &cacheData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cache data: %w", err)
+	}
+
+	// Check if the cache has expired
+	if time.Now().After(cacheData.ExpiresAt) {
+		b.Clear() // Clean up expired cache
+		return nil, nil
+	}
+
+	// The cache holds the key of another store
+	if cacheData.Store != absPath(storePath) {
+		return nil, nil
+	}
+
+	return cacheData.Key, nil
+}
+
+// Clear removes the cached key and its wrapping key.
+//
+// Returns:
+//   - error: Any error that occurred during removal
+func (fileBackend) Clear() error {
+	var firstErr error
+	for _, path := range []string{wrappingKeyFile(), cacheFile, legacyFile} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// newGCM creates an AES-GCM cipher for the wrapping key.
+// GCM (Galois/Counter Mode) provides both confidentiality and data integrity
+// (verifies data hasn't been tampered with).
+func newGCM(wrappingKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(wrappingKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
+
+// writeFile writes data to a temporary file with 0600 permissions, then
+// renames it for atomicity.
+// This ensures the file isn't corrupted if the write operation is interrupted
+func writeFile(path string, data []byte) error {
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile) // Clean up the temp file on error
+		return err
+	}
+	return nil
+}
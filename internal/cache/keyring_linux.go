@@ -0,0 +1,159 @@
+//go:build linux
+
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	keyringKeyType     = "user"            // Kernel key type holding arbitrary data
+	keyringDescription = "vlxck:store-key" // Description the key is found by
+)
+
+// keyringBackend keeps the key in the Linux session keyring. The kernel
+// drops the key when its timeout expires, and it never touches the disk.
+type keyringBackend struct {
+	ring int // ID of the session keyring
+}
+
+// newKeyringBackend returns the keyring backend if keyctl is usable.
+func newKeyringBackend() (Backend, bool) {
+	// Resolve the session keyring without creating one: a process without a
+	// session keyring gets the persistent user-session keyring, whereas a newly
+	// created session keyring would disappear when the command exits
+	ring, err := unix.KeyctlGetKeyringID(unix.KEY_SPEC_SESSION_KEYRING, false)
+	if err != nil {
+		return nil, false
+	}
+	return keyringBackend{ring: ring}, true
+}
+
+// Name returns the name of the backend.
+func (keyringBackend) Name() string {
+	return BackendKeyring
+}
+
+// Set adds the store key to the session keyring and sets its timeout,
+// replacing any key cached before. If timeout is less than or equal to 0,
+// the cache is cleared.
+//
+// Parameters:
+//   - storePath: Path to the store file the key belongs to
+//   - key: The derived store key
+//   - timeout: The duration for which the key should be cached
+//
+// Returns:
+//   - error: Any error that occurred during caching
+func (b keyringBackend) Set(storePath string, key *store.Key, timeout time.Duration) error {
+	if timeout <= 0 {
+		return b.Clear()
+	}
+
+	payload, err := json.Marshal(cacheData{
+		Store:     absPath(storePath),
+		Key:       key,
+		ExpiresAt: time.Now().Add(timeout),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache data: %w", err)
+	}
+	defer wipe(payload)
+
+	id, err := unix.AddKey(keyringKeyType, keyringDescription, payload, b.ring)
+	if err != nil {
+		return fmt.Errorf("failed to add key to keyring: %w", err)
+	}
+
+	seconds := int(math.Ceil(timeout.Seconds()))
+	if _, err := unix.KeyctlInt(unix.KEYCTL_SET_TIMEOUT, id, seconds, 0, 0); err != nil {
+		// Never leave a key behind without a timeout
+		unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0)
+		return fmt.Errorf("failed to set key timeout: %w", err)
+	}
+	return nil
+}
+
+// Get reads the cached key for the store from the session keyring.
+//
+// Parameters:
+//   - storePath: Path to the store file
+//
+// Returns:
+//   - *store.Key: The cached key, or nil if no usable key is cached
+//   - error: Any error that occurred during retrieval
+func (b keyringBackend) Get(storePath string) (*store.Key, error) {
+	id, err := b.search()
+	if err != nil || id == 0 {
+		return nil, err
+	}
+
+	// Ask for the payload size first, then read the payload
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	if err != nil {
+		return nil, keyringError(err)
+	}
+	payload := make([]byte, size)
+	defer wipe(payload)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, payload, 0)
+	if err != nil {
+		return nil, keyringError(err)
+	}
+	if n < size {
+		payload = payload[:n]
+	}
+
+	var cacheData cacheData
+	if err := json.Unmarshal(payload, &cacheData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cache data: %w", err)
+	}
+
+	// The keyring holds the key of another store
+	if cacheData.Store != absPath(storePath) {
+		return nil, nil
+	}
+
+	return cacheData.Key, nil
+}
+
+// Clear invalidates the cached key, which removes it from the keyring at once.
+//
+// Returns:
+//   - error: Any error that occurred during removal
+func (b keyringBackend) Clear() error {
+	id, err := b.search()
+	if err != nil || id == 0 {
+		return err
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0); err != nil {
+		return keyringError(err)
+	}
+	return nil
+}
+
+// search returns the ID of the cached key, or 0 if there is none.
+func (b keyringBackend) search() (int, error) {
+	id, err := unix.KeyctlSearch(b.ring, keyringKeyType, keyringDescription, 0)
+	if err != nil {
+		if err := keyringError(err); err != nil {
+			return 0, err
+		}
+		return 0, nil
+	}
+	return id, nil
+}
+
+// keyringError turns errors meaning that the key is gone into nil.
+func keyringError(err error) error {
+	if errors.Is(err, unix.ENOKEY) || errors.Is(err, unix.EKEYEXPIRED) || errors.Is(err, unix.EKEYREVOKED) {
+		return nil
+	}
+	return fmt.Errorf("keyring: %w", err)
+}
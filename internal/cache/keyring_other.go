@@ -0,0 +1,9 @@
+//go:build !linux
+
+package cache
+
+// newKeyringBackend reports that the kernel keyring is not available on
+// this platform.
+func newKeyringBackend() (Backend, bool) {
+	return nil, false
+}
@@ -5,22 +5,77 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/kirinyoku/vlxck/internal/utils"
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
 
-// Config represents the synchronization configuration
+// Defaults for the key cache when the configuration does not say otherwise.
+const (
+	DefaultCacheTimeout = 5 * time.Minute // How long the derived store key is cached
+	DefaultCacheBackend = "file"          // Where the derived store key is cached
+)
+
+// Defaults for the backup retention policy when the configuration does not say otherwise.
+const (
+	DefaultBackupKeepLast    = 10 // Number of newest backups kept
+	DefaultBackupKeepDaily   = 7  // Number of days the newest backup is kept for
+	DefaultBackupKeepWeekly  = 4  // Number of weeks the newest backup is kept for
+	DefaultBackupKeepMonthly = 12 // Number of months the newest backup is kept for
+)
+
+// DefaultSnapshotKeep is the number of snapshots taken before store changes
+// that are kept for 'vlxck undo' when the configuration does not say otherwise.
+const DefaultSnapshotKeep = 20
+
+// Config represents the application configuration
 type Config struct {
+	Cache struct {
+		// Timeout is how long the derived store key is cached (0 disables caching)
+		Timeout time.Duration `mapstructure:"timeout"`
+		// Backend selects where the key is cached: file or keyring (Linux only)
+		Backend string `mapstructure:"backend"`
+	} `mapstructure:"cache"`
 	Sync struct {
 		Provider              string `mapstructure:"provider"`
+		Auto                  bool   `mapstructure:"auto"`
 		FileID                string `mapstructure:"file_id"`
 		Etag                  string `mapstructure:"etag"`
 		EncryptedToken        []byte `mapstructure:"encrypted_token"`
 		EncryptedClientId     []byte `mapstructure:"encrypted_client_id"`
 		EncryptedClientSecret []byte `mapstructure:"encrypted_client_secret"`
+		WebDAV                struct {
+			URL               string `mapstructure:"url"`
+			EncryptedUsername []byte `mapstructure:"encrypted_username"`
+			EncryptedPassword []byte `mapstructure:"encrypted_password"`
+		} `mapstructure:"webdav"`
+		S3 struct {
+			Endpoint           string `mapstructure:"endpoint"` // Empty for AWS
+			Region             string `mapstructure:"region"`
+			Bucket             string `mapstructure:"bucket"`
+			Key                string `mapstructure:"key"`
+			PathStyle          bool   `mapstructure:"path_style"`
+			EncryptedAccessKey []byte `mapstructure:"encrypted_access_key"`
+			EncryptedSecretKey []byte `mapstructure:"encrypted_secret_key"`
+		} `mapstructure:"s3"`
+		Git struct {
+			Remote string `mapstructure:"remote"`
+			Branch string `mapstructure:"branch"`
+		} `mapstructure:"git"`
 	} `mapstructure:"sync"`
+	// Backup is the retention policy applied by 'vlxck backup prune'
+	Backup struct {
+		KeepLast    int `mapstructure:"keep_last"`
+		KeepDaily   int `mapstructure:"keep_daily"`
+		KeepWeekly  int `mapstructure:"keep_weekly"`
+		KeepMonthly int `mapstructure:"keep_monthly"`
+	} `mapstructure:"backup"`
+	Snapshots struct {
+		// Keep is the number of snapshots kept for undo (0 disables snapshots)
+		Keep int `mapstructure:"keep"`
+	} `mapstructure:"snapshots"`
 }
 
 // LoadConfig loads the configuration from file
@@ -28,12 +83,19 @@ func LoadConfig() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(filepath.Join(os.Getenv("HOME"), ".vlxck"))
+	viper.SetDefault("cache.timeout", DefaultCacheTimeout)
+	viper.SetDefault("cache.backend", DefaultCacheBackend)
+	viper.SetDefault("backup.keep_last", DefaultBackupKeepLast)
+	viper.SetDefault("backup.keep_daily", DefaultBackupKeepDaily)
+	viper.SetDefault("backup.keep_weekly", DefaultBackupKeepWeekly)
+	viper.SetDefault("backup.keep_monthly", DefaultBackupKeepMonthly)
+	viper.SetDefault("snapshots.keep", DefaultSnapshotKeep)
 
 	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			return &Config{}, nil
+		// Without a config file, the defaults apply
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return nil, fmt.Errorf("failed to read config: %v", err)
 		}
-		return nil, fmt.Errorf("failed to read config: %v", err)
 	}
 
 	var config Config
@@ -45,12 +107,32 @@ func LoadConfig() (*Config, error) {
 
 // SaveConfig saves the configuration to file
 func SaveConfig(config *Config) error {
+	viper.Set("cache.timeout", config.Cache.Timeout.String())
+	viper.Set("cache.backend", config.Cache.Backend)
 	viper.Set("sync.provider", config.Sync.Provider)
 	viper.Set("sync.file_id", config.Sync.FileID)
 	viper.Set("sync.etag", config.Sync.Etag)
 	viper.Set("sync.encrypted_token", config.Sync.EncryptedToken)
 	viper.Set("sync.encrypted_client_id", config.Sync.EncryptedClientId)
 	viper.Set("sync.encrypted_client_secret", config.Sync.EncryptedClientSecret)
+	viper.Set("sync.webdav.url", config.Sync.WebDAV.URL)
+	viper.Set("sync.webdav.encrypted_username", config.Sync.WebDAV.EncryptedUsername)
+	viper.Set("sync.webdav.encrypted_password", config.Sync.WebDAV.EncryptedPassword)
+	viper.Set("sync.s3.endpoint", config.Sync.S3.Endpoint)
+	viper.Set("sync.s3.region", config.Sync.S3.Region)
+	viper.Set("sync.s3.bucket", config.Sync.S3.Bucket)
+	viper.Set("sync.s3.key", config.Sync.S3.Key)
+	viper.Set("sync.s3.path_style", config.Sync.S3.PathStyle)
+	viper.Set("sync.s3.encrypted_access_key", config.Sync.S3.EncryptedAccessKey)
+	viper.Set("sync.s3.encrypted_secret_key", config.Sync.S3.EncryptedSecretKey)
+	viper.Set("sync.git.remote", config.Sync.Git.Remote)
+	viper.Set("sync.git.branch", config.Sync.Git.Branch)
+	viper.Set("sync.auto", config.Sync.Auto)
+	viper.Set("backup.keep_last", config.Backup.KeepLast)
+	viper.Set("backup.keep_daily", config.Backup.KeepDaily)
+	viper.Set("backup.keep_weekly", config.Backup.KeepWeekly)
+	viper.Set("backup.keep_monthly", config.Backup.KeepMonthly)
+	viper.Set("snapshots.keep", config.Snapshots.Keep)
 
 	configPath := filepath.Join(os.Getenv("HOME"), ".vlxck", "config.yaml")
 	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
@@ -139,3 +221,148 @@ func DecryptClientCredentials(encryptedClientID, encryptedClientSecret []byte, p
 
 	return string(clientID), string(clientSecret), nil
 }
+
+// EncryptCredentials encrypts a username and password (or an access key pair)
+// used to authenticate with a sync provider
+func EncryptCredentials(username, secret, password string) ([]byte, []byte, error) {
+	if password == "" {
+		return nil, nil, fmt.Errorf("password cannot be empty")
+	}
+
+	encryptedUsername, err := utils.EncryptFile([]byte(username), password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt username: %v", err)
+	}
+
+	encryptedSecret, err := utils.EncryptFile([]byte(secret), password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt secret: %v", err)
+	}
+
+	return encryptedUsername, encryptedSecret, nil
+}
+
+// DecryptCredentials decrypts a username and password (or an access key pair)
+// encrypted with EncryptCredentials
+func DecryptCredentials(encryptedUsername, encryptedSecret []byte, password string) (string, string, error) {
+	if len(encryptedUsername) == 0 || len(encryptedSecret) == 0 {
+		return "", "", fmt.Errorf("encrypted credentials are empty")
+	}
+
+	username, err := utils.DecryptFile(encryptedUsername, password)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decrypt username: %v", err)
+	}
+
+	secret, err := utils.DecryptFile(encryptedSecret, password)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decrypt secret: %v", err)
+	}
+
+	return string(username), string(secret), nil
+}
+
+// credentialFields returns the encrypted sync credentials of the config by
+// their config keys.
+func credentialFields(config *Config) map[string]*[]byte {
+	return map[string]*[]byte{
+		"sync.encrypted_token":           &config.Sync.EncryptedToken,
+		"sync.encrypted_client_id":       &config.Sync.EncryptedClientId,
+		"sync.encrypted_client_secret":   &config.Sync.EncryptedClientSecret,
+		"sync.webdav.encrypted_username": &config.Sync.WebDAV.EncryptedUsername,
+		"sync.webdav.encrypted_password": &config.Sync.WebDAV.EncryptedPassword,
+		"sync.s3.encrypted_access_key":   &config.Sync.S3.EncryptedAccessKey,
+		"sync.s3.encrypted_secret_key":   &config.Sync.S3.EncryptedSecretKey,
+	}
+}
+
+// Credentials holds the encrypted sync credentials of a config by their
+// config keys, e.g. to bring them back when a change of the master password
+// is undone.
+type Credentials map[string][]byte
+
+// SyncCredentials returns the encrypted sync credentials kept in the config.
+//
+// Parameters:
+//   - config: The configuration holding the credentials
+//
+// Returns:
+//   - Credentials: The credentials that are set (empty if there are none)
+func SyncCredentials(config *Config) Credentials {
+	credentials := make(Credentials)
+	for name, field := range credentialFields(config) {
+		if len(*field) > 0 {
+			credentials[name] = *field
+		}
+	}
+	return credentials
+}
+
+// SetSyncCredentials replaces the encrypted sync credentials of the config
+// with ones returned by SyncCredentials. Credentials that are not given are
+// cleared.
+//
+// Parameters:
+//   - config: The configuration to change
+//   - credentials: The encrypted credentials
+func SetSyncCredentials(config *Config, credentials Credentials) {
+	for name, field := range credentialFields(config) {
+		*field = credentials[name]
+	}
+}
+
+// CheckCredentials checks that the sync credentials kept in the config are
+// encrypted with the password.
+//
+// Parameters:
+//   - config: The configuration holding the credentials
+//   - password: The master password
+//
+// Returns:
+//   - error: An error if a credential does not decrypt with the password
+func CheckCredentials(config *Config, password string) error {
+	for _, field := range credentialFields(config) {
+		if len(*field) == 0 {
+			continue
+		}
+		if _, err := utils.DecryptFile(*field, password); err != nil {
+			return fmt.Errorf("failed to decrypt sync credentials: %v", err)
+		}
+	}
+	return nil
+}
+
+// ReencryptCredentials re-encrypts the sync credentials kept in the config,
+// which are encrypted with the master password, for a new master password.
+// Nothing is changed unless every credential decrypts with the old password.
+//
+// Parameters:
+//   - config: The configuration holding the credentials
+//   - oldPassword: The master password the credentials are encrypted with
+//   - newPassword: The new master password
+//
+// Returns:
+//   - bool: Whether the config holds any credentials and must be saved
+//   - error: An error if a credential cannot be decrypted or encrypted
+func ReencryptCredentials(config *Config, oldPassword, newPassword string) (bool, error) {
+	fields := credentialFields(config)
+	reencrypted := make(map[string][]byte, len(fields))
+	for name, field := range fields {
+		if len(*field) == 0 {
+			continue
+		}
+		data, err := utils.DecryptFile(*field, oldPassword)
+		if err != nil {
+			return false, fmt.Errorf("failed to decrypt sync credentials: %v", err)
+		}
+		reencrypted[name], err = utils.EncryptFile(data, newPassword)
+		if err != nil {
+			return false, fmt.Errorf("failed to encrypt sync credentials: %v", err)
+		}
+	}
+
+	for name, data := range reencrypted {
+		*fields[name] = data
+	}
+	return len(reencrypted) > 0, nil
+}
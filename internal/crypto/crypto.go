@@ -7,10 +7,39 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"time"
 
 	"golang.org/x/crypto/argon2"
 )
 
+const (
+	// KDFArgon2id identifies Argon2id as the key derivation function in store headers.
+	KDFArgon2id uint8 = 1
+
+	// Upper bounds for Argon2id parameters, so a crafted store header cannot
+	// make key derivation take an unbounded amount of time or memory.
+	MaxKDFTime   = 64
+	MaxKDFMemory = 4 * 1024 * 1024 // 4 GiB in KiB
+)
+
+// KDFParams holds the tunable Argon2id cost parameters.
+type KDFParams struct {
+	// Time is the number of passes over the memory
+	Time uint32
+	// Memory is the amount of memory used in KiB
+	Memory uint32
+	// Threads is the degree of parallelism
+	Threads uint8
+}
+
+// DefaultKDFParams are the Argon2id parameters used for new stores and for
+// stores written before the parameters were recorded in the file header.
+var DefaultKDFParams = KDFParams{
+	Time:    1,
+	Memory:  64 * 1024,
+	Threads: 4,
+}
+
 // DeriveKey generates a cryptographic key from a password and salt using Argon2id.
 // It's designed to be computationally intensive to prevent brute force attacks.
 //
@@ -21,13 +50,71 @@ import (
 // Returns:
 //   - A 32-byte key suitable for use with AES-256
 //
-// Note: The function uses the following Argon2id parameters:
+// Note: The function uses DefaultKDFParams:
 //   - Time: 1 iteration (trade-off between security and performance)
 //   - Memory: 64MB (64 * 1024 KB)
 //   - Threads: 4
 //   - Key length: 32 bytes (256 bits)
 func DeriveKey(password string, salt []byte) []byte {
-	return argon2.IDKey([]byte(password), salt, 1, 64*1024, 4, 32)
+	return DeriveKeyWithParams(password, salt, DefaultKDFParams)
+}
+
+// DeriveKeyWithParams generates a 32-byte key from a password and salt using
+// Argon2id with the given cost parameters.
+//
+// Parameters:
+//   - password: The plaintext password to derive the key from
+//   - salt: A cryptographically secure random salt
+//   - params: The Argon2id cost parameters
+//
+// Returns:
+//   - A 32-byte key suitable for use with AES-256
+func DeriveKeyWithParams(password string, salt []byte, params KDFParams) []byte {
+	return argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, 32)
+}
+
+// CalibrateKDFParams benchmarks Argon2id on the current machine and returns
+// parameters whose key derivation takes roughly the target duration.
+// Memory and parallelism are fixed by the caller; only the number of passes is tuned.
+//
+// Parameters:
+//   - target: The desired key derivation time (e.g. 500ms)
+//   - memory: The amount of memory to use in KiB
+//   - threads: The degree of parallelism
+//
+// Returns:
+//   - KDFParams: The proposed parameters
+//   - time.Duration: The measured key derivation time with those parameters
+func CalibrateKDFParams(target time.Duration, memory uint32, threads uint8) (KDFParams, time.Duration) {
+	salt := make([]byte, 16)
+	params := KDFParams{Time: 1, Memory: memory, Threads: threads}
+	elapsed := measureKDF(salt, params)
+
+	// Scale the number of passes by the measured time; a few rounds smooth
+	// out warm-up effects of the first measurement.
+	for i := 0; i < 3 && elapsed > 0; i++ {
+		passes := (int64(params.Time)*int64(target) + int64(elapsed)/2) / int64(elapsed)
+		if passes < 1 {
+			passes = 1
+		}
+		if passes > MaxKDFTime {
+			passes = MaxKDFTime
+		}
+		if uint32(passes) == params.Time {
+			break
+		}
+		params.Time = uint32(passes)
+		elapsed = measureKDF(salt, params)
+	}
+
+	return params, elapsed
+}
+
+// measureKDF returns how long a single key derivation takes with the given parameters.
+func measureKDF(salt []byte, params KDFParams) time.Duration {
+	start := time.Now()
+	DeriveKeyWithParams("vlxck-benchmark", salt, params)
+	return time.Since(start)
 }
 
 // Encrypt encrypts the given plaintext using AES-256-GCM (Galois/Counter Mode).
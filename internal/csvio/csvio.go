@@ -0,0 +1,334 @@
+// Package csvio reads and writes secrets as CSV, for moving them between the
+// store and spreadsheets or other password managers. CSV files hold secret
+// values in plaintext.
+package csvio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// Secret fields that can be read from and written to CSV columns.
+const (
+	FieldName     = "name"
+	FieldValue    = "value"
+	FieldCategory = "category"
+	FieldTags     = "tags"
+	FieldUsername = "username"
+	FieldURLs     = "urls"
+	FieldNotes    = "notes"
+)
+
+// Fields lists the secret fields in the column order used for export, and
+// for import from CSV files without a header.
+var Fields = []string{FieldName, FieldValue, FieldCategory, FieldTags, FieldUsername, FieldURLs, FieldNotes}
+
+// aliases are the header names recognized for each field, as used by common
+// password managers and spreadsheets. Matching ignores case and surrounding space.
+var aliases = map[string][]string{
+	FieldName:     {"name", "title", "account", "site", "service"},
+	FieldValue:    {"value", "password", "pass", "secret", "login_password"},
+	FieldCategory: {"category", "folder", "group", "grouping"},
+	FieldTags:     {"tags", "tag", "labels"},
+	FieldUsername: {"username", "user", "login", "login_username", "email"},
+	FieldURLs:     {"urls", "url", "uri", "login_uri", "website"},
+	FieldNotes:    {"notes", "note", "comments", "extra"},
+}
+
+// listSeparator separates multiple tags or URLs within one CSV cell.
+const listSeparator = ","
+
+// Spreadsheets evaluate cells starting with one of the formulaChars as a
+// formula. On export such cells get the formulaEscape prefix, which makes
+// spreadsheets show them as text; import can remove it again.
+const (
+	formulaChars  = "=+-@\t\r"
+	formulaEscape = "'"
+)
+
+// Mapping maps secret fields to CSV columns, given as header names or as
+// 1-based column numbers. Fields without a mapping use their own name or a
+// recognized alias.
+type Mapping map[string]string
+
+// ParseMapping parses a mapping given as comma-separated field=column pairs,
+// e.g. "name=title,value=password,category=group".
+//
+// Parameters:
+//   - spec: The mapping specification (empty for no mapping)
+//
+// Returns:
+//   - Mapping: The parsed mapping
+//   - error: An error if a pair is malformed or names an unknown field
+func ParseMapping(spec string) (Mapping, error) {
+	mapping := make(Mapping)
+	if strings.TrimSpace(spec) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		column = strings.TrimSpace(column)
+		if !ok || field == "" || column == "" {
+			return nil, fmt.Errorf("invalid mapping '%s', expected field=column", pair)
+		}
+		if _, known := aliases[field]; !known {
+			return nil, fmt.Errorf("unknown field '%s' in mapping (fields: %s)", field, strings.Join(Fields, ", "))
+		}
+		if _, dup := mapping[field]; dup {
+			return nil, fmt.Errorf("field '%s' is mapped more than once", field)
+		}
+		mapping[field] = column
+	}
+	return mapping, nil
+}
+
+// columnNumber returns the 0-based index of a column given as 1-based number.
+func columnNumber(column string) (int, bool) {
+	n, err := strconv.Atoi(column)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// Read parses secrets from CSV. If the first row names columns (by mapping or
+// by a recognized alias) so that the name and value columns are known, it is
+// the header and columns are found by name; otherwise all rows are data in
+// the Fields order, with column numbers from the mapping taking precedence.
+// Cells are taken verbatim unless unescape is set, which is only right for
+// files written by Write: other tools do not escape formulas, so a value such
+// as '=abc would lose its quote.
+//
+// Parameters:
+//   - r: The CSV data
+//   - mapping: Maps fields to header names or column numbers
+//   - unescape: Whether to remove the prefix Write adds to formula-like cells
+//
+// Returns:
+//   - []store.Secret: The secrets, with creation and update time set to now
+//   - error: An error if the CSV is malformed, a mapped column is missing or
+//     a row has no name or a duplicate name
+func Read(r io.Reader, mapping Mapping, unescape bool) ([]store.Secret, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+	// Spreadsheets often start UTF-8 files with a byte order mark
+	records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+
+	columns, isHeader, err := resolveColumns(records[0], mapping)
+	if err != nil {
+		return nil, err
+	}
+	if isHeader {
+		records = records[1:]
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	secrets := make([]store.Secret, 0, len(records))
+	for i, record := range records {
+		row := i + 1
+		if isHeader {
+			row++
+		}
+		raw := func(field string) string {
+			if index, ok := columns[field]; ok && index < len(record) {
+				if unescape {
+					return unescapeCell(record[index])
+				}
+				return record[index]
+			}
+			return ""
+		}
+		cell := func(field string) string {
+			return strings.TrimSpace(raw(field))
+		}
+
+		// Spaces may be part of a password, so the value is not trimmed
+		secret := store.Secret{
+			Name:      cell(FieldName),
+			Value:     raw(FieldValue),
+			Category:  store.NormalizeFolder(cell(FieldCategory)),
+			Tags:      store.NormalizeTags(splitList(cell(FieldTags))),
+			Username:  cell(FieldUsername),
+			URLs:      splitList(cell(FieldURLs)),
+			Notes:     cell(FieldNotes),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if secret.Name == "" {
+			return nil, fmt.Errorf("row %d: missing name", row)
+		}
+		if seen[secret.Name] {
+			return nil, fmt.Errorf("row %d: duplicate secret name '%s'", row, secret.Name)
+		}
+		seen[secret.Name] = true
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+// resolveColumns finds the column index of each field and reports whether
+// the first row is a header.
+func resolveColumns(first []string, mapping Mapping) (map[string]int, bool, error) {
+	byName := make(map[string]int)
+	for i, cell := range first {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		if _, dup := byName[name]; !dup {
+			byName[name] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	var missing []string
+	named := false // Whether any column was found by its header name
+	for _, field := range Fields {
+		if column, ok := mapping[field]; ok {
+			if index, ok := columnNumber(column); ok {
+				columns[field] = index
+			} else if index, ok := byName[strings.ToLower(column)]; ok {
+				columns[field] = index
+				named = true
+			} else {
+				missing = append(missing, column)
+			}
+			continue
+		}
+		for _, alias := range aliases[field] {
+			if index, ok := byName[alias]; ok {
+				columns[field] = index
+				named = true
+				break
+			}
+		}
+	}
+
+	_, hasName := columns[FieldName]
+	_, hasValue := columns[FieldValue]
+	if named && hasName && hasValue && len(missing) == 0 {
+		return columns, true, nil
+	}
+	if len(missing) > 0 && named {
+		return nil, false, fmt.Errorf("column '%s' not found in the CSV header", missing[0])
+	}
+
+	// No header: columns are in the Fields order unless mapped by number
+	columns = make(map[string]int)
+	for i, field := range Fields {
+		columns[field] = i
+		if column, ok := mapping[field]; ok {
+			index, ok := columnNumber(column)
+			if !ok {
+				return nil, false, fmt.Errorf("column '%s' not found: the CSV file has no header", column)
+			}
+			columns[field] = index
+		}
+	}
+	return columns, false, nil
+}
+
+// splitList splits a cell holding several tags or URLs.
+func splitList(cell string) []string {
+	var items []string
+	for _, item := range strings.Split(cell, listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Header returns the header row written on export: the field names in the
+// Fields order, with mapped fields under their mapped names.
+//
+// Returns:
+//   - []string: The header row
+//   - error: An error if the mapping uses column numbers, which only work for import
+func (m Mapping) Header() ([]string, error) {
+	header := make([]string, len(Fields))
+	for i, field := range Fields {
+		header[i] = field
+		if column, ok := m[field]; ok {
+			if _, isNumber := columnNumber(column); isNumber {
+				return nil, fmt.Errorf("column numbers can only be mapped for import, not '%s=%s'", field, column)
+			}
+			header[i] = column
+		}
+	}
+	return header, nil
+}
+
+// escapeCell prefixes a cell that a spreadsheet would take for a formula
+// with formulaEscape. Cells that only look like an escaped formula are
+// prefixed as well, so that unescapeCell restores every cell exactly.
+func escapeCell(cell string) string {
+	unquoted := strings.TrimLeft(cell, formulaEscape)
+	if unquoted != "" && strings.ContainsRune(formulaChars, rune(unquoted[0])) {
+		return formulaEscape + cell
+	}
+	return cell
+}
+
+// unescapeCell removes the prefix added by escapeCell.
+func unescapeCell(cell string) string {
+	if unescaped, ok := strings.CutPrefix(cell, formulaEscape); ok && escapeCell(unescaped) == cell {
+		return unescaped
+	}
+	return cell
+}
+
+// Write writes the secrets as CSV with the header row of the mapping. Cells
+// starting with =, +, -, @, a tab or a carriage return are prefixed with ' so
+// that spreadsheets do not run them as formulas; Read removes the prefix if
+// asked to.
+//
+// Parameters:
+//   - w: Where to write the CSV data
+//   - secrets: The secrets to write
+//   - mapping: Maps fields to header names
+//
+// Returns:
+//   - error: An error if the mapping uses column numbers or writing fails
+func Write(w io.Writer, secrets []store.Secret, mapping Mapping) error {
+	header, err := mapping.Header()
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, secret := range secrets {
+		record := []string{
+			secret.Name,
+			secret.Value,
+			secret.Category,
+			strings.Join(secret.Tags, listSeparator),
+			secret.Username,
+			strings.Join(secret.URLs, listSeparator),
+			secret.Notes,
+		}
+		for i := range record {
+			record[i] = escapeCell(record[i])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,105 @@
+// Package inject renders configuration templates that reference secrets.
+//
+// Templates use Go text/template syntax with a vlxck function:
+//
+//	password: {{ vlxck "prod/db" }}
+//	username: {{ vlxck "prod/db" "username" }}
+//
+// Bare references such as vlxck://prod/db#password are also replaced
+// anywhere outside template actions.
+package inject
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// Resolver returns the value a secret reference points at.
+type Resolver func(ref store.Reference) (string, error)
+
+// bareReference matches vlxck://secret[#field] references in plain text.
+// Dots are only matched between other characters, so a reference at the end
+// of a sentence does not swallow the period. Secret names containing other
+// characters (e.g. spaces) must use the template function instead.
+var bareReference = regexp.MustCompile(`vlxck://[\w~@+/-]+(?:\.[\w~@+/-]+)*(?:#[\w-]+(?:\.[\w-]+)*)?`)
+
+// Render executes the template, resolving every secret reference.
+// Rendering fails as a whole if any reference cannot be resolved.
+//
+// Parameters:
+//   - name: The template name used in error messages (e.g. the file name)
+//   - text: The template source
+//   - resolve: The function used to look up references
+//
+// Returns:
+//   - []byte: The rendered output
+//   - error: Any error that occurred while parsing or executing the template
+func Render(name, text string, resolve Resolver) ([]byte, error) {
+	funcs := template.FuncMap{
+		"vlxck": func(secret string, field ...string) (string, error) {
+			if len(field) > 1 {
+				return "", fmt.Errorf("vlxck takes a secret and at most one field, got %d fields", len(field))
+			}
+			spec := secret
+			if len(field) == 1 {
+				spec += "#" + field[0]
+			}
+			ref, err := store.ParseReference(spec)
+			if err != nil {
+				return "", err
+			}
+			return resolve(ref)
+		},
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(rewriteBareReferences(text))
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, nil); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// rewriteBareReferences turns bare vlxck:// references outside template
+// actions into calls to the vlxck function, so that all references are
+// resolved in a single pass and resolved values are never re-interpreted.
+func rewriteBareReferences(text string) string {
+	var out strings.Builder
+	for text != "" {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			out.WriteString(replaceBareReferences(text))
+			break
+		}
+		out.WriteString(replaceBareReferences(text[:start]))
+
+		// Copy the action unchanged; an unterminated action is left
+		// for the template parser to report
+		end := strings.Index(text[start:], "}}")
+		if end < 0 {
+			out.WriteString(text[start:])
+			break
+		}
+		end += start + len("}}")
+		out.WriteString(text[start:end])
+		text = text[end:]
+	}
+	return out.String()
+}
+
+// replaceBareReferences rewrites the bare references in a text segment.
+func replaceBareReferences(text string) string {
+	return bareReference.ReplaceAllStringFunc(text, func(ref string) string {
+		return "{{ vlxck " + strconv.Quote(ref) + " }}"
+	})
+}
@@ -0,0 +1,114 @@
+// Package output renders command results in machine-readable formats
+// (JSON, YAML and TSV) for use in scripts. Rendered output never contains
+// prompts or ANSI colors, and field names are stable across releases.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format selected with the global --output flag.
+type Format string
+
+const (
+	Text Format = "text" // Human-readable output (default)
+	JSON Format = "json" // Indented JSON
+	YAML Format = "yaml" // YAML
+	TSV  Format = "tsv"  // Tab-separated values with a header row
+)
+
+// Formats lists all supported output formats.
+var Formats = []Format{Text, JSON, YAML, TSV}
+
+// ParseFormat parses an output format name. The empty string selects Text.
+//
+// Parameters:
+//   - name: The format name (text, json, yaml or tsv)
+//
+// Returns:
+//   - Format: The parsed format
+//   - error: An error if the format is not supported
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	if format == "" {
+		return Text, nil
+	}
+	for _, f := range Formats {
+		if f == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported output format '%s' (use text, json, yaml or tsv)", name)
+}
+
+// IsStructured reports whether the format is machine-readable.
+func (f Format) IsStructured() bool {
+	return f != Text && f != ""
+}
+
+// Tabular is implemented by results that can be rendered as TSV.
+type Tabular interface {
+	// Header returns the column names
+	Header() []string
+	// Rows returns one row of cells per record, in Header order
+	Rows() [][]string
+}
+
+// Write renders the result in the given structured format.
+//
+// Parameters:
+//   - w: The writer to render to
+//   - format: The output format (JSON, YAML or TSV)
+//   - result: The result to render; it is marshalled as-is for JSON and YAML
+//
+// Returns:
+//   - error: Any error that occurred while rendering
+func Write(w io.Writer, format Format, result Tabular) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	case YAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case TSV:
+		return writeTSV(w, result)
+	default:
+		return fmt.Errorf("format '%s' is not a structured output format", format)
+	}
+}
+
+// writeTSV writes the header and rows separated by tabs.
+func writeTSV(w io.Writer, result Tabular) error {
+	if _, err := fmt.Fprintln(w, joinTSV(result.Header())); err != nil {
+		return err
+	}
+	for _, row := range result.Rows() {
+		if _, err := fmt.Fprintln(w, joinTSV(row)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tsvEscaper escapes characters that would break the row and column structure.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// joinTSV escapes the cells and joins them with tabs.
+func joinTSV(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = tsvEscaper.Replace(cell)
+	}
+	return strings.Join(escaped, "\t")
+}
@@ -0,0 +1,258 @@
+package output
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/backup"
+	"github.com/kirinyoku/vlxck/internal/snapshot"
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// timeLayout is used for timestamps in TSV output.
+const timeLayout = time.RFC3339
+
+// Secret is the machine-readable representation of a secret.
+// Sensitive values (the secret value and hidden custom fields) are omitted
+// unless revealed.
+type Secret struct {
+	Name      string    `json:"name" yaml:"name"`
+	Value     *string   `json:"value,omitempty" yaml:"value,omitempty"`
+	Category  string    `json:"category" yaml:"category"`
+	Tags      []string  `json:"tags" yaml:"tags"`
+	Username  string    `json:"username" yaml:"username"`
+	URLs      []string  `json:"urls" yaml:"urls"`
+	Notes     string    `json:"notes" yaml:"notes"`
+	Fields    []Field   `json:"fields" yaml:"fields"`
+	TOTP      *TOTP     `json:"totp,omitempty" yaml:"totp,omitempty"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" yaml:"updated_at"`
+}
+
+// Field is the machine-readable representation of a custom field.
+type Field struct {
+	Name  string  `json:"name" yaml:"name"`
+	Type  string  `json:"type" yaml:"type"`
+	Value *string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// TOTP describes the code generation parameters of a TOTP secret.
+// The seed itself is the secret value.
+type TOTP struct {
+	Algorithm string `json:"algorithm" yaml:"algorithm"`
+	Digits    int    `json:"digits" yaml:"digits"`
+	Period    int    `json:"period" yaml:"period"`
+	Issuer    string `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	Account   string `json:"account,omitempty" yaml:"account,omitempty"`
+}
+
+// NewSecret converts a stored secret into its machine-readable representation.
+//
+// Parameters:
+//   - secret: The secret to convert
+//   - reveal: Whether to include the value and hidden custom fields
+//
+// Returns:
+//   - Secret: The converted secret
+func NewSecret(secret store.Secret, reveal bool) Secret {
+	result := Secret{
+		Name:      secret.Name,
+		Category:  secret.Category,
+		Tags:      nonNil(secret.Tags),
+		Username:  secret.Username,
+		URLs:      nonNil(secret.URLs),
+		Notes:     secret.Notes,
+		Fields:    make([]Field, 0, len(secret.Fields)),
+		CreatedAt: secret.CreatedAt,
+		UpdatedAt: secret.UpdatedAt,
+	}
+	if reveal {
+		result.Value = &secret.Value
+	}
+	for _, f := range secret.Fields {
+		field := Field{Name: f.Name, Type: string(f.Type)}
+		if reveal || f.Type != store.FieldHidden {
+			value := f.Value
+			field.Value = &value
+		}
+		result.Fields = append(result.Fields, field)
+	}
+	if secret.TOTP != nil {
+		result.TOTP = &TOTP{
+			Algorithm: string(secret.TOTP.Algorithm),
+			Digits:    secret.TOTP.Digits,
+			Period:    secret.TOTP.Period,
+			Issuer:    secret.TOTP.Issuer,
+			Account:   secret.TOTP.Account,
+		}
+	}
+	return result
+}
+
+// Header returns the TSV columns for a secret. Custom fields are not part of
+// the TSV output; use JSON or YAML to read them.
+func (s Secret) Header() []string {
+	return []string{"name", "category", "tags", "username", "urls", "notes", "created_at", "updated_at", "value"}
+}
+
+// Rows returns the secret as a single TSV row. Tags and URLs are
+// comma-separated; the value is empty unless revealed.
+func (s Secret) Rows() [][]string {
+	value := ""
+	if s.Value != nil {
+		value = *s.Value
+	}
+	return [][]string{{
+		s.Name,
+		s.Category,
+		strings.Join(s.Tags, ","),
+		s.Username,
+		strings.Join(s.URLs, ","),
+		s.Notes,
+		formatTime(s.CreatedAt),
+		formatTime(s.UpdatedAt),
+		value,
+	}}
+}
+
+// Secrets is a list of secrets, rendered as an array.
+type Secrets []Secret
+
+// NewSecrets converts stored secrets into their machine-readable representation.
+func NewSecrets(secrets []store.Secret, reveal bool) Secrets {
+	result := make(Secrets, 0, len(secrets))
+	for _, secret := range secrets {
+		result = append(result, NewSecret(secret, reveal))
+	}
+	return result
+}
+
+// Header returns the TSV columns for a list of secrets.
+func (s Secrets) Header() []string {
+	return Secret{}.Header()
+}
+
+// Rows returns one TSV row per secret.
+func (s Secrets) Rows() [][]string {
+	rows := make([][]string, 0, len(s))
+	for _, secret := range s {
+		rows = append(rows, secret.Rows()...)
+	}
+	return rows
+}
+
+// Backup is the machine-readable representation of a backup file.
+type Backup struct {
+	Name       string    `json:"name" yaml:"name"`
+	Path       string    `json:"path" yaml:"path"`
+	Size       int64     `json:"size_bytes" yaml:"size_bytes"`
+	ModifiedAt time.Time `json:"modified_at" yaml:"modified_at"`
+	Encrypted  bool      `json:"encrypted" yaml:"encrypted"`
+}
+
+// Backups is a list of backups, rendered as an array.
+type Backups []Backup
+
+// NewBackups converts backup metadata into its machine-readable representation.
+func NewBackups(backups []backup.BackupInfo) Backups {
+	result := make(Backups, 0, len(backups))
+	for _, b := range backups {
+		result = append(result, Backup{Name: b.Name, Path: b.Path, Size: b.Size, ModifiedAt: b.ModTime, Encrypted: b.Encrypted})
+	}
+	return result
+}
+
+// Header returns the TSV columns for a list of backups.
+func (b Backups) Header() []string {
+	return []string{"name", "path", "size_bytes", "modified_at", "encrypted"}
+}
+
+// Rows returns one TSV row per backup.
+func (b Backups) Rows() [][]string {
+	rows := make([][]string, 0, len(b))
+	for _, backup := range b {
+		rows = append(rows, []string{backup.Name, backup.Path, strconv.FormatInt(backup.Size, 10), formatTime(backup.ModifiedAt), strconv.FormatBool(backup.Encrypted)})
+	}
+	return rows
+}
+
+// Snapshot is the machine-readable representation of a store snapshot.
+type Snapshot struct {
+	Name      string    `json:"name" yaml:"name"`
+	Command   string    `json:"command" yaml:"command"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	Size      int64     `json:"size_bytes" yaml:"size_bytes"`
+}
+
+// Snapshots is a list of snapshots, rendered as an array.
+type Snapshots []Snapshot
+
+// NewSnapshots converts snapshot metadata into its machine-readable representation.
+func NewSnapshots(snapshots []snapshot.Snapshot) Snapshots {
+	result := make(Snapshots, 0, len(snapshots))
+	for _, s := range snapshots {
+		result = append(result, Snapshot{Name: s.Name, Command: s.Command, CreatedAt: s.CreatedAt, Size: s.Size})
+	}
+	return result
+}
+
+// Header returns the TSV columns for a list of snapshots.
+func (s Snapshots) Header() []string {
+	return []string{"name", "command", "created_at", "size_bytes"}
+}
+
+// Rows returns one TSV row per snapshot.
+func (s Snapshots) Rows() [][]string {
+	rows := make([][]string, 0, len(s))
+	for _, snapshot := range s {
+		rows = append(rows, []string{snapshot.Name, snapshot.Command, formatTime(snapshot.CreatedAt), strconv.FormatInt(snapshot.Size, 10)})
+	}
+	return rows
+}
+
+// Password is the machine-readable result of password generation.
+// The password is omitted unless revealed.
+type Password struct {
+	Value   *string `json:"value,omitempty" yaml:"value,omitempty"`
+	Length  int     `json:"length" yaml:"length"`
+	Symbols bool    `json:"symbols" yaml:"symbols"`
+	Digits  bool    `json:"digits" yaml:"digits"`
+	Copied  bool    `json:"copied" yaml:"copied"`
+}
+
+// Header returns the TSV columns for a generated password.
+func (p Password) Header() []string {
+	return []string{"length", "symbols", "digits", "copied", "value"}
+}
+
+// Rows returns the generated password as a single TSV row.
+func (p Password) Rows() [][]string {
+	value := ""
+	if p.Value != nil {
+		value = *p.Value
+	}
+	return [][]string{{
+		strconv.Itoa(p.Length),
+		strconv.FormatBool(p.Symbols),
+		strconv.FormatBool(p.Digits),
+		strconv.FormatBool(p.Copied),
+		value,
+	}}
+}
+
+// nonNil returns an empty slice instead of nil so that JSON renders [] rather than null.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// formatTime formats a timestamp for TSV output, or "" if it is unset.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(timeLayout)
+}
@@ -0,0 +1,180 @@
+// Package search implements ranked fuzzy matching of secrets by name,
+// category, username and URL.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// Field weights: a match in the name counts more than one in the metadata.
+const (
+	nameWeight     = 3
+	usernameWeight = 2
+	categoryWeight = 2
+	urlWeight      = 1
+)
+
+// Match is a secret together with its relevance score.
+type Match struct {
+	Secret store.Secret
+	Score  int
+}
+
+// Rank returns the secrets matching the query, best matches first.
+// The query is split into whitespace-separated terms; every term must match
+// at least one field of a secret. Ties are broken by name.
+//
+// Parameters:
+//   - secrets: The secrets to search
+//   - query: The search query
+//
+// Returns:
+//   - []Match: The matching secrets ordered by descending score
+func Rank(secrets []store.Secret, query string) []Match {
+	order, scores := rank(secrets, query)
+	matches := make([]Match, len(order))
+	for i, idx := range order {
+		matches[i] = Match{Secret: secrets[idx], Score: scores[idx]}
+	}
+	return matches
+}
+
+// Order returns the indices of the secrets matching the query in the same
+// order as Rank. It lets callers such as interactive selectors rank secrets
+// without copying them.
+//
+// Parameters:
+//   - secrets: The secrets to search
+//   - query: The search query
+//
+// Returns:
+//   - []int: Indices into secrets, best matches first
+func Order(secrets []store.Secret, query string) []int {
+	order, _ := rank(secrets, query)
+	return order
+}
+
+// rank scores every secret and returns the indices of the matching ones,
+// sorted by descending score and then by name, along with all scores.
+func rank(secrets []store.Secret, query string) ([]int, []int) {
+	scores := make([]int, len(secrets))
+	var order []int
+	for i := range secrets {
+		if scores[i] = Score(&secrets[i], query); scores[i] > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return secrets[a].Name < secrets[b].Name
+	})
+	return order, scores
+}
+
+// Score returns the relevance of the secret for the query, or 0 if it does not match.
+// An empty query matches every secret with a score of 1.
+//
+// Parameters:
+//   - secret: The secret to score
+//   - query: The search query
+//
+// Returns:
+//   - int: The relevance score
+func Score(secret *store.Secret, query string) int {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return 1
+	}
+
+	total := 0
+	for _, term := range terms {
+		best := nameWeight * scoreText(term, secret.Name)
+		best = max(best, usernameWeight*scoreText(term, secret.Username))
+		best = max(best, categoryWeight*scoreText(term, secret.Category))
+		for _, url := range secret.URLs {
+			best = max(best, urlWeight*scoreText(term, url))
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// scoreText scores a single lower-case term against a text.
+// Exact, prefix and substring matches rank above scattered (subsequence) matches.
+func scoreText(term, text string) int {
+	if text == "" {
+		return 0
+	}
+	text = strings.ToLower(text)
+
+	switch {
+	case text == term:
+		return 100
+	case strings.HasPrefix(text, term):
+		return 80
+	}
+	if idx := strings.Index(text, term); idx >= 0 {
+		if isBoundary(text, idx) {
+			return 70
+		}
+		return 60
+	}
+	return scoreSubsequence(term, text)
+}
+
+// scoreSubsequence scores a match where the term's characters appear in order
+// but not contiguously, e.g. "gthb" in "github". Consecutive characters and
+// characters at word boundaries earn a bonus. Returns 0 if there is no match.
+func scoreSubsequence(term, text string) int {
+	t := []rune(text)
+	score := 10
+	pos := 0
+	prev := -2
+	for _, r := range term {
+		found := false
+		for pos < len(t) {
+			if t[pos] == r {
+				found = true
+				break
+			}
+			pos++
+		}
+		if !found {
+			return 0
+		}
+		if pos == prev+1 {
+			score += 3
+		}
+		if pos == 0 || !unicode.IsLetter(t[pos-1]) && !unicode.IsDigit(t[pos-1]) {
+			score += 2
+		}
+		prev = pos
+		pos++
+	}
+
+	// Prefer tighter matches
+	if span := prev - len([]rune(term)); span > 0 {
+		score -= min(span, 8)
+	}
+	return min(max(score, 1), 50)
+}
+
+// isBoundary reports whether the byte offset starts a word in text.
+func isBoundary(text string, idx int) bool {
+	if idx == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(text[:idx])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,194 @@
+// Package snapshot keeps copies of the store taken before commands modify it,
+// so that the last changes can be undone. Snapshots are copies of the store
+// file and therefore encrypted like the store itself.
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	dirName         = "snapshots"                 // Directory next to the store that holds the snapshots
+	ext             = ".dat"                      // Extension of snapshot files
+	attachmentExt   = ".attachment"               // Extension of data kept with a snapshot
+	timestampLayout = "20060102-150405.000000000" // Timestamp in snapshot file names
+)
+
+// Snapshot describes a copy of the store taken before a command modified it.
+type Snapshot struct {
+	Path      string
+	Name      string
+	Command   string    // The command that modified the store after the snapshot was taken
+	CreatedAt time.Time // When the snapshot was taken
+	Size      int64
+}
+
+// Dir returns the directory holding the snapshots of the store.
+func Dir(storePath string) string {
+	return filepath.Join(filepath.Dir(storePath), dirName)
+}
+
+// attachmentPath returns the path of the data kept with the snapshot.
+func (s Snapshot) attachmentPath() string {
+	return strings.TrimSuffix(s.Path, ext) + attachmentExt
+}
+
+// Attachment returns the data kept with the snapshot by Take.
+//
+// Returns:
+//   - []byte: The data, or nil if none was kept
+//   - error: Any error that occurred while reading the data
+func (s Snapshot) Attachment() ([]byte, error) {
+	data, err := os.ReadFile(s.attachmentPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot attachment: %w", err)
+	}
+	return data, nil
+}
+
+// remove deletes the snapshot and the data kept with it.
+func (s Snapshot) remove() error {
+	if err := os.Remove(s.attachmentPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Remove(s.Path)
+}
+
+// Take copies the store before the command modifies it, then deletes the
+// oldest snapshots so that at most keep remain. Nothing is taken if the store
+// does not exist yet or keep is 0.
+//
+// Parameters:
+//   - storePath: Path to the encrypted store file
+//   - command: The command about to modify the store, e.g. "import"
+//   - keep: The number of snapshots to keep
+//   - attachment: Data to keep with the snapshot, e.g. config values the
+//     command changes along with the store (nil for none)
+//
+// Returns:
+//   - error: Any error that occurred while copying the store or deleting old snapshots
+func Take(storePath, command string, keep int, attachment []byte) error {
+	if keep <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(storePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read store: %w", err)
+	}
+
+	dir := Dir(storePath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	taken := Snapshot{Path: filepath.Join(dir, time.Now().Format(timestampLayout)+"_"+command+ext)}
+	if attachment != nil {
+		if err := os.WriteFile(taken.attachmentPath(), attachment, 0600); err != nil {
+			return fmt.Errorf("failed to write snapshot attachment: %w", err)
+		}
+	}
+	if err := os.WriteFile(taken.Path, data, 0600); err != nil {
+		taken.remove()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	snapshots, err := List(storePath)
+	if err != nil {
+		return err
+	}
+	for _, s := range snapshots[min(keep, len(snapshots)):] {
+		if err := s.remove(); err != nil {
+			return fmt.Errorf("failed to delete snapshot %s: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// List returns the snapshots of the store, newest first.
+//
+// Parameters:
+//   - storePath: Path to the encrypted store file
+//
+// Returns:
+//   - []Snapshot: The snapshots
+//   - error: Any error that occurred while reading the snapshot directory
+func List(storePath string) ([]Snapshot, error) {
+	dir := Dir(storePath)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		timestamp, command, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ext), "_")
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ext) || !ok {
+			continue
+		}
+		createdAt, err := time.ParseInLocation(timestampLayout, timestamp, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			Path:      filepath.Join(dir, entry.Name()),
+			Name:      entry.Name(),
+			Command:   command,
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Restore replaces the store with the snapshot and deletes the snapshot and
+// the data kept with it, so that restoring the newest snapshot repeatedly
+// steps further back. Read the data with Attachment before.
+//
+// Parameters:
+//   - storePath: Path to the encrypted store file
+//   - s: The snapshot to restore
+//
+// Returns:
+//   - error: Any error that occurred while replacing the store
+func Restore(storePath string, s Snapshot) error {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	// Write next to the store and rename, so that a failed write leaves the store intact
+	tmpPath := storePath + ".undo"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := os.Rename(tmpPath, storePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace store: %w", err)
+	}
+
+	if err := s.remove(); err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	return nil
+}
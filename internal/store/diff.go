@@ -0,0 +1,51 @@
+package store
+
+import "sort"
+
+// Changes lists the names of the secrets that differ between two copies of
+// the store.
+type Changes struct {
+	// Added are the secrets only found in the other copy
+	Added []string
+	// Changed are the secrets found in both copies with different contents
+	Changed []string
+	// Removed are the secrets missing from the other copy
+	Removed []string
+}
+
+// IsEmpty reports whether both copies hold the same secrets.
+func (c Changes) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Changed) == 0 && len(c.Removed) == 0
+}
+
+// Diff compares the store with another copy of it, e.g. one read from a
+// backup, and returns what replacing the store with the other copy would change.
+//
+// Parameters:
+//   - s: The store
+//   - other: The other copy of the store
+//
+// Returns:
+//   - Changes: The secrets added, changed and removed by the other copy, sorted by name
+func (s *Store) Diff(other *Store) Changes {
+	var changes Changes
+	for _, secret := range other.Secrets {
+		current := s.entryOf(secret.Name)
+		switch {
+		case current.secret == nil:
+			changes.Added = append(changes.Added, secret.Name)
+		case !current.sameAs(entry{secret: &secret}):
+			changes.Changed = append(changes.Changed, secret.Name)
+		}
+	}
+	for _, secret := range s.Secrets {
+		if other.entryOf(secret.Name).secret == nil {
+			changes.Removed = append(changes.Removed, secret.Name)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Removed)
+	return changes
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// FieldType describes how a custom field value should be treated.
+type FieldType string
+
+const (
+	FieldText   FieldType = "text"   // Plain text, shown as-is
+	FieldHidden FieldType = "hidden" // Sensitive value, masked when displayed
+	FieldURL    FieldType = "url"    // Web address
+	FieldEmail  FieldType = "email"  // Email address
+)
+
+// MaskedValue is displayed in place of sensitive values.
+const MaskedValue = "********"
+
+// FieldTypes lists all supported custom field types.
+var FieldTypes = []FieldType{FieldText, FieldHidden, FieldURL, FieldEmail}
+
+// CustomField is an arbitrary user-defined field attached to a secret.
+type CustomField struct {
+	// Name identifies the field within its secret
+	Name string `json:"name"`
+	// Type determines how the value is validated and displayed
+	Type FieldType `json:"type"`
+	// Value is the field content
+	Value string `json:"value"`
+}
+
+// Validate checks that the field has a name, a known type and a value
+// matching that type.
+func (f CustomField) Validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("field name cannot be empty")
+	}
+	switch f.Type {
+	case FieldText, FieldHidden:
+	case FieldURL:
+		if u, err := url.Parse(f.Value); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("field '%s': invalid URL '%s'", f.Name, f.Value)
+		}
+	case FieldEmail:
+		if _, err := mail.ParseAddress(f.Value); err != nil {
+			return fmt.Errorf("field '%s': invalid email '%s'", f.Name, f.Value)
+		}
+	default:
+		return fmt.Errorf("field '%s': unknown type '%s'", f.Name, f.Type)
+	}
+	return nil
+}
+
+// DisplayValue returns the field value, masked for hidden fields.
+func (f CustomField) DisplayValue() string {
+	if f.Type == FieldHidden {
+		return MaskedValue
+	}
+	return f.Value
+}
+
+// ParseCustomField parses a field specification of the form
+// "[type:]name=value", e.g. "pin=1234" or "hidden:pin=1234".
+// The type defaults to text.
+//
+// Parameters:
+//   - spec: The field specification
+//
+// Returns:
+//   - CustomField: The parsed and validated field
+//   - error: Any error that occurred during parsing or validation
+func ParseCustomField(spec string) (CustomField, error) {
+	key, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		return CustomField{}, fmt.Errorf("invalid field '%s': expected [type:]name=value", spec)
+	}
+
+	field := CustomField{Name: key, Type: FieldText, Value: value}
+	if typ, name, ok := strings.Cut(key, ":"); ok {
+		field.Type = FieldType(strings.ToLower(typ))
+		field.Name = name
+	}
+
+	if err := field.Validate(); err != nil {
+		return CustomField{}, err
+	}
+	return field, nil
+}
+
+// SetField adds the field to the secret, replacing any field with the same name.
+func (s *Secret) SetField(field CustomField) {
+	for i := range s.Fields {
+		if s.Fields[i].Name == field.Name {
+			s.Fields[i] = field
+			return
+		}
+	}
+	s.Fields = append(s.Fields, field)
+}
+
+// RemoveField removes the named field and reports whether it existed.
+func (s *Secret) RemoveField(name string) bool {
+	for i := range s.Fields {
+		if s.Fields[i].Name == name {
+			s.Fields = append(s.Fields[:i], s.Fields[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the value of a named field of the secret.
+// The built-in names "value", "username", "url" (first URL), "notes" and
+// "category" are checked first, then custom fields.
+//
+// Parameters:
+//   - name: The field name
+//
+// Returns:
+//   - string: The field value
+//   - bool: Whether the field exists
+func (s *Secret) Lookup(name string) (string, bool) {
+	switch name {
+	case "value", "password":
+		return s.Value, true
+	case "username":
+		return s.Username, true
+	case "url":
+		if len(s.URLs) == 0 {
+			return "", true
+		}
+		return s.URLs[0], true
+	case "notes":
+		return s.Notes, true
+	case "category":
+		return s.Category, true
+	}
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field.Value, true
+		}
+	}
+	return "", false
+}
+
+// IsTOTP reports whether the secret value is a TOTP seed.
+func (s *Secret) IsTOTP() bool {
+	return s.TOTP != nil
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NormalizeFolder cleans up a folder path such as " work//aws/prod/ " into
+// "work/aws/prod". Categories are folder paths separated by '/'.
+//
+// Parameters:
+//   - folder: The folder path to normalize
+//
+// Returns:
+//   - string: The normalized folder path ("" for the root)
+func NormalizeFolder(folder string) string {
+	var parts []string
+	for _, part := range strings.Split(folder, "/") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// NormalizeTags trims, de-duplicates and sorts tags, dropping empty ones.
+//
+// Parameters:
+//   - tags: The tags to normalize
+//
+// Returns:
+//   - []string: The normalized tags
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// InFolder reports whether the secret is in the folder or one of its subfolders.
+// The empty folder matches every secret.
+func (s *Secret) InFolder(folder string) bool {
+	folder = NormalizeFolder(folder)
+	return folder == "" || s.Category == folder || strings.HasPrefix(s.Category, folder+"/")
+}
+
+// HasTag reports whether the secret carries the given tag.
+func (s *Secret) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter selects secrets by folder subtree and tag expression.
+// A secret matches when it is in Folder, has every tag in Include
+// and none of the tags in Exclude.
+type Filter struct {
+	// Folder restricts matches to this folder and its subfolders
+	Folder string
+	// Include lists tags a secret must have
+	Include []string
+	// Exclude lists tags a secret must not have
+	Exclude []string
+}
+
+// ParseFilter builds a filter from a folder and tag expressions.
+// A tag expression is a tag name, or a tag name prefixed with '!' to exclude it,
+// e.g. ["prod", "!legacy"].
+//
+// Parameters:
+//   - folder: The folder subtree to match ("" for all)
+//   - tagExprs: The tag expressions
+//
+// Returns:
+//   - Filter: The parsed filter
+//   - error: An error if a tag expression is empty
+func ParseFilter(folder string, tagExprs []string) (Filter, error) {
+	filter := Filter{Folder: NormalizeFolder(folder)}
+	for _, expr := range tagExprs {
+		expr = strings.TrimSpace(expr)
+		exclude := strings.HasPrefix(expr, "!")
+		tag := strings.TrimSpace(strings.TrimPrefix(expr, "!"))
+		if tag == "" {
+			return Filter{}, fmt.Errorf("invalid tag expression '%s'", expr)
+		}
+		if exclude {
+			filter.Exclude = append(filter.Exclude, tag)
+		} else {
+			filter.Include = append(filter.Include, tag)
+		}
+	}
+	return filter, nil
+}
+
+// IsEmpty reports whether the filter matches every secret.
+func (f Filter) IsEmpty() bool {
+	return f.Folder == "" && len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Match reports whether the secret satisfies the filter.
+func (f Filter) Match(secret *Secret) bool {
+	if !secret.InFolder(f.Folder) {
+		return false
+	}
+	for _, tag := range f.Include {
+		if !secret.HasTag(tag) {
+			return false
+		}
+	}
+	for _, tag := range f.Exclude {
+		if secret.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the secrets that satisfy the filter, preserving their order.
+func (f Filter) Apply(secrets []Secret) []Secret {
+	var result []Secret
+	for i := range secrets {
+		if f.Match(&secrets[i]) {
+			result = append(result, secrets[i])
+		}
+	}
+	return result
+}
+
+// String describes the filter for messages such as "No secrets found in ...".
+func (f Filter) String() string {
+	var parts []string
+	if f.Folder != "" {
+		parts = append(parts, "folder "+f.Folder)
+	}
+	for _, tag := range f.Include {
+		parts = append(parts, "tag "+tag)
+	}
+	for _, tag := range f.Exclude {
+		parts = append(parts, "without tag "+tag)
+	}
+	return strings.Join(parts, ", ")
+}
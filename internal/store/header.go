@@ -0,0 +1,175 @@
+package store
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/kirinyoku/vlxck/internal/crypto"
+)
+
+const (
+	// FormatVersion is the current version of the store file header
+	FormatVersion uint8 = 1
+
+	legacySaltLen = 16 // Salt length used by headerless store files
+	saltLen       = 16 // Salt length used for new store files
+	nonceLen      = 12 // AES-GCM nonce length
+
+	// fixedHeaderLen is the header size without the salt:
+	// magic(4) + version(1) + kdf(1) + time(4) + memory(4) + threads(1) + salt length(1)
+	fixedHeaderLen = 16
+)
+
+// magic identifies a store file with a versioned header.
+var magic = []byte("VLXK")
+
+// Header describes how the key for a store file is derived.
+// Store files written by older versions have no header; they are read as
+// Version 0 with DefaultKDFParams and a 16-byte salt.
+type Header struct {
+	// Version is the header format version (0 for headerless files)
+	Version uint8
+	// KDF identifies the key derivation function
+	KDF uint8
+	// Params holds the key derivation cost parameters
+	Params crypto.KDFParams
+	// Salt is the random salt used for key derivation
+	Salt []byte
+}
+
+// NewHeader creates a header for a new store file with a fresh random salt
+// and the given key derivation parameters.
+//
+// Parameters:
+//   - params: The Argon2id cost parameters
+//
+// Returns:
+//   - *Header: The new header
+//   - error: Any error that occurred while generating the salt
+func NewHeader(params crypto.KDFParams) (*Header, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &Header{
+		Version: FormatVersion,
+		KDF:     crypto.KDFArgon2id,
+		Params:  params,
+		Salt:    salt,
+	}, nil
+}
+
+// DeriveKey derives the store key from the password using the header's
+// salt and key derivation parameters.
+func (h *Header) DeriveKey(password string) []byte {
+	return crypto.DeriveKeyWithParams(password, h.Salt, h.Params)
+}
+
+// IsLegacy reports whether the header was read from a headerless store file.
+func (h *Header) IsLegacy() bool {
+	return h.Version == 0
+}
+
+// marshal encodes the header in its binary on-disk form.
+func (h *Header) marshal() []byte {
+	buf := make([]byte, fixedHeaderLen, fixedHeaderLen+len(h.Salt))
+	copy(buf, magic)
+	buf[4] = FormatVersion
+	buf[5] = h.KDF
+	binary.BigEndian.PutUint32(buf[6:10], h.Params.Time)
+	binary.BigEndian.PutUint32(buf[10:14], h.Params.Memory)
+	buf[14] = h.Params.Threads
+	buf[15] = uint8(len(h.Salt))
+	return append(buf, h.Salt...)
+}
+
+// parseFile splits raw store file data into its header, nonce and ciphertext.
+// Files without the magic bytes are treated as the legacy
+// [16-byte salt][12-byte nonce][ciphertext] layout.
+func parseFile(data []byte) (*Header, []byte, []byte, error) {
+	if !bytes.HasPrefix(data, magic) {
+		if len(data) < legacySaltLen+nonceLen {
+			return nil, nil, nil, fmt.Errorf("store file is too short")
+		}
+		header := &Header{
+			KDF:    crypto.KDFArgon2id,
+			Params: crypto.DefaultKDFParams,
+			Salt:   data[:legacySaltLen],
+		}
+		rest := data[legacySaltLen:]
+		return header, rest[:nonceLen], rest[nonceLen:], nil
+	}
+
+	if len(data) < fixedHeaderLen {
+		return nil, nil, nil, fmt.Errorf("store header is truncated")
+	}
+
+	header := &Header{
+		Version: data[4],
+		KDF:     data[5],
+		Params: crypto.KDFParams{
+			Time:    binary.BigEndian.Uint32(data[6:10]),
+			Memory:  binary.BigEndian.Uint32(data[10:14]),
+			Threads: data[14],
+		},
+	}
+	if err := header.validate(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	n := int(data[15])
+	if n == 0 {
+		return nil, nil, nil, fmt.Errorf("store header has an empty salt")
+	}
+	if len(data) < fixedHeaderLen+n+nonceLen {
+		return nil, nil, nil, fmt.Errorf("store file is too short")
+	}
+	header.Salt = data[fixedHeaderLen : fixedHeaderLen+n]
+
+	rest := data[fixedHeaderLen+n:]
+	return header, rest[:nonceLen], rest[nonceLen:], nil
+}
+
+// validate checks that the header describes something this version can read.
+func (h *Header) validate() error {
+	if h.Version != FormatVersion {
+		return fmt.Errorf("unsupported store format version %d", h.Version)
+	}
+	if h.KDF != crypto.KDFArgon2id {
+		return fmt.Errorf("unsupported key derivation function %d", h.KDF)
+	}
+	if h.Params.Time == 0 || h.Params.Time > crypto.MaxKDFTime {
+		return fmt.Errorf("invalid KDF time parameter %d", h.Params.Time)
+	}
+	if h.Params.Memory < 8*uint32(h.Params.Threads) || h.Params.Memory > crypto.MaxKDFMemory {
+		return fmt.Errorf("invalid KDF memory parameter %d", h.Params.Memory)
+	}
+	if h.Params.Threads == 0 {
+		return fmt.Errorf("invalid KDF parallelism parameter %d", h.Params.Threads)
+	}
+	return nil
+}
+
+// ReadHeader reads the header of an existing store file without decrypting it.
+// Headerless files produce a legacy header (see Header.IsLegacy).
+//
+// Parameters:
+//   - filePath: Path to the encrypted store file
+//
+// Returns:
+//   - *Header: The store file header
+//   - error: Any error that occurred while reading or parsing the file
+func ReadHeader(filePath string) (*Header, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	header, _, _, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
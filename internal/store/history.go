@@ -0,0 +1,58 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxHistory is the number of previous values kept for each secret.
+const MaxHistory = 10
+
+// HistoryEntry records a value a secret held before it was changed.
+type HistoryEntry struct {
+	// Value is the previous secret value
+	Value string `json:"value"`
+	// ChangedAt records when the value was replaced
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// SetValue replaces the secret value, pushing the old value onto the history.
+// The history is trimmed to MaxHistory entries. Setting the same value is a no-op.
+//
+// Parameters:
+//   - value: The new secret value
+func (s *Secret) SetValue(value string) {
+	if value == s.Value {
+		return
+	}
+	if s.Value != "" {
+		entry := HistoryEntry{Value: s.Value, ChangedAt: time.Now()}
+		s.History = append([]HistoryEntry{entry}, s.History...)
+		if len(s.History) > MaxHistory {
+			s.History = s.History[:MaxHistory]
+		}
+	}
+	s.Value = value
+}
+
+// Rollback restores the value from the given history entry.
+// The current value is pushed onto the history, so a rollback can itself be undone.
+//
+// Parameters:
+//   - n: The 1-based history index, where 1 is the most recent previous value
+//
+// Returns:
+//   - error: An error if n is out of range or the entry holds the current
+//     value, in which case the history is left unchanged
+func (s *Secret) Rollback(n int) error {
+	if n < 1 || n > len(s.History) {
+		return fmt.Errorf("history entry %d does not exist (secret '%s' has %d)", n, s.Name, len(s.History))
+	}
+	entry := s.History[n-1]
+	if entry.Value == s.Value {
+		return fmt.Errorf("history entry %d of secret '%s' is the current value", n, s.Name)
+	}
+	s.History = append(s.History[:n-1], s.History[n:]...)
+	s.SetValue(entry.Value)
+	return nil
+}
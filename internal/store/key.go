@@ -0,0 +1,138 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kirinyoku/vlxck/internal/crypto"
+)
+
+// ErrKeyMismatch is returned when a key was derived for a different salt or
+// different KDF parameters than those of the store file, e.g. after the
+// master password was changed or the store was rekeyed.
+var ErrKeyMismatch = errors.New("key does not match the store header")
+
+// Key is a store encryption key derived from the master password.
+// It is bound to the salt and KDF parameters it was derived with, so it can
+// unlock a store without the password but stops matching once the salt rotates.
+type Key struct {
+	// Salt is the salt the key was derived with
+	Salt []byte `json:"salt"`
+	// Params are the KDF parameters the key was derived with
+	Params crypto.KDFParams `json:"params"`
+	// Bytes is the derived AES-256 key
+	Bytes []byte `json:"key"`
+}
+
+// Key derives the store key for the password using the header's salt and
+// key derivation parameters.
+func (h *Header) Key(password string) *Key {
+	return &Key{
+		Salt:   append([]byte(nil), h.Salt...),
+		Params: h.Params,
+		Bytes:  h.DeriveKey(password),
+	}
+}
+
+// Matches reports whether the key was derived for the header's salt and parameters.
+func (k *Key) Matches(h *Header) bool {
+	return bytes.Equal(k.Salt, h.Salt) && k.Params == h.Params
+}
+
+// header returns a header for writing a new store file with this key.
+func (k *Key) header() *Header {
+	return &Header{
+		Version: FormatVersion,
+		KDF:     crypto.KDFArgon2id,
+		Params:  k.Params,
+		Salt:    append([]byte(nil), k.Salt...),
+	}
+}
+
+// Clone returns a copy of the key that does not share memory with it, so
+// that wiping one leaves the other intact.
+func (k *Key) Clone() *Key {
+	return &Key{
+		Salt:   append([]byte(nil), k.Salt...),
+		Params: k.Params,
+		Bytes:  append([]byte(nil), k.Bytes...),
+	}
+}
+
+// Wipe overwrites the key material in memory.
+func (k *Key) Wipe() {
+	for i := range k.Bytes {
+		k.Bytes[i] = 0
+	}
+}
+
+// DeriveKey derives the key for an existing store file from the master password.
+// The password is not verified; loading the store with the key verifies it.
+//
+// Parameters:
+//   - filePath: Path to the encrypted store file
+//   - password: The master password
+//
+// Returns:
+//   - *Key: The derived key
+//   - error: Any error that occurred while reading the store header
+func DeriveKey(filePath, password string) (*Key, error) {
+	header, err := ReadHeader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return header.Key(password), nil
+}
+
+// LoadStoreWithKey reads and decrypts the store using a derived key
+// instead of the master password.
+//
+// Parameters:
+//   - filePath: Path to the encrypted store file
+//   - key: The key derived for the store's header
+//
+// Returns:
+//   - *Store: The decrypted store
+//   - error: ErrKeyMismatch if the key belongs to another header, or any error
+//     that occurred during reading, decryption or JSON unmarshaling
+func LoadStoreWithKey(filePath string, key *Key) (*Store, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+	header, nonce, encrypted, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Matches(header) {
+		return nil, ErrKeyMismatch
+	}
+	return decryptStore(encrypted, key.Bytes, nonce)
+}
+
+// SaveStoreWithKey encrypts and writes the store using a derived key.
+// The key must match the header of the existing file; if the file does not
+// exist, it is created with the key's salt and parameters.
+//
+// Parameters:
+//   - filePath: Path where the store should be saved
+//   - key: The key derived for the store's header
+//   - store: Pointer to the Store struct to be saved
+//
+// Returns:
+//   - error: ErrKeyMismatch if the file was rekeyed in the meantime, or any
+//     error that occurred during encryption or file operations
+func SaveStoreWithKey(filePath string, key *Key, store *Store) error {
+	header, err := ReadHeader(filePath)
+	switch {
+	case os.IsNotExist(err):
+		header = key.header()
+	case err != nil:
+		return err
+	case !key.Matches(header):
+		return ErrKeyMismatch
+	}
+	return writeStore(filePath, header, key.Bytes, store)
+}
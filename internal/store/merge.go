@@ -0,0 +1,197 @@
+package store
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Tombstone records that a secret was deleted, so that a merge with a copy
+// of the store that still has the secret does not bring it back.
+type Tombstone struct {
+	// Name is the name of the deleted secret
+	Name string `json:"name"`
+	// DeletedAt records when the secret was deleted
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Delete removes the secret with the given name and records a tombstone for it.
+//
+// Parameters:
+//   - name: The name of the secret
+//
+// Returns:
+//   - bool: True if the secret existed
+func (s *Store) Delete(name string) bool {
+	for i, secret := range s.Secrets {
+		if secret.Name == name {
+			s.Secrets = append(s.Secrets[:i], s.Secrets[i+1:]...)
+			s.setTombstone(Tombstone{Name: name, DeletedAt: time.Now()})
+			return true
+		}
+	}
+	return false
+}
+
+// tombstone returns the tombstone for the name, or nil if there is none.
+func (s *Store) tombstone(name string) *Tombstone {
+	for i := range s.Deleted {
+		if s.Deleted[i].Name == name {
+			return &s.Deleted[i]
+		}
+	}
+	return nil
+}
+
+// setTombstone adds the tombstone, replacing any older one for the same name.
+func (s *Store) setTombstone(t Tombstone) {
+	if existing := s.tombstone(t.Name); existing != nil {
+		if t.DeletedAt.After(existing.DeletedAt) {
+			*existing = t
+		}
+		return
+	}
+	s.Deleted = append(s.Deleted, t)
+}
+
+// entry is the state of one secret name in a copy of the store: a live
+// secret, a tombstone, or neither.
+type entry struct {
+	secret    *Secret
+	tombstone *Tombstone
+}
+
+// entryOf returns the state of the name in the store. A live secret wins over
+// a stale tombstone left from before it was re-added.
+func (s *Store) entryOf(name string) entry {
+	if s == nil {
+		return entry{}
+	}
+	for i := range s.Secrets {
+		if s.Secrets[i].Name == name {
+			return entry{secret: &s.Secrets[i]}
+		}
+	}
+	return entry{tombstone: s.tombstone(name)}
+}
+
+// changedAt returns when the entry was last changed.
+func (e entry) changedAt() time.Time {
+	switch {
+	case e.secret != nil:
+		return e.secret.UpdatedAt
+	case e.tombstone != nil:
+		return e.tombstone.DeletedAt
+	}
+	return time.Time{}
+}
+
+// sameAs reports whether two entries hold the same secret, or are both
+// deleted or missing.
+func (e entry) sameAs(other entry) bool {
+	if e.secret == nil || other.secret == nil {
+		return e.secret == nil && other.secret == nil
+	}
+	return reflect.DeepEqual(*e.secret, *other.secret)
+}
+
+// MergeStats counts what a merge took from each side.
+type MergeStats struct {
+	// Local is the number of secrets changed only locally
+	Local int
+	// Remote is the number of secrets changed only remotely
+	Remote int
+	// Conflicts is the number of secrets changed differently on both sides
+	Conflicts int
+}
+
+// Merge performs a three-way merge of two copies of the store that both
+// descend from base, secret by secret:
+//   - A secret changed on one side only takes that side's version.
+//   - A secret deleted on one side and changed on the other is kept if it was
+//     changed after the deletion (by UpdatedAt), and deleted otherwise.
+//   - A secret changed differently on both sides is a conflict and is passed
+//     to resolve, which returns the version to keep.
+//
+// Without a base (nil), secrets found on one side only are kept unless the
+// other side deleted them later, and every secret that differs is a conflict.
+//
+// Parameters:
+//   - base: The store as of the last sync, or nil if unknown
+//   - local: The local store
+//   - remote: The remote store
+//   - resolve: Chooses between the local and remote version of a conflicting secret
+//
+// Returns:
+//   - *Store: The merged store
+//   - MergeStats: What was taken from each side
+func Merge(base, local, remote *Store, resolve func(local, remote Secret) Secret) (*Store, MergeStats) {
+	var stats MergeStats
+	merged := &Store{Version: CurrentVersion, Secrets: []Secret{}}
+
+	// Visit names in a stable order: local secrets first, then new remote ones
+	var names []string
+	seen := map[string]bool{}
+	for _, s := range []*Store{local, remote} {
+		for _, secret := range s.Secrets {
+			if !seen[secret.Name] {
+				seen[secret.Name] = true
+				names = append(names, secret.Name)
+			}
+		}
+	}
+	for _, s := range []*Store{local, remote} {
+		for _, t := range s.Deleted {
+			if !seen[t.Name] {
+				seen[t.Name] = true
+				names = append(names, t.Name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		b, l, r := base.entryOf(name), local.entryOf(name), remote.entryOf(name)
+
+		var result entry
+		switch {
+		case l.sameAs(r):
+			result = l
+			// Both deleted: keep the latest deletion
+			if l.secret == nil && r.changedAt().After(l.changedAt()) {
+				result = r
+			}
+		case base != nil && l.sameAs(b):
+			result = r
+			stats.Remote++
+		case base != nil && r.sameAs(b):
+			result = l
+			stats.Local++
+		case l.secret == nil || r.secret == nil:
+			// Deleted on one side, changed or added on the other: the later wins.
+			// A secret missing without a tombstone counts as never deleted.
+			if l.changedAt().After(r.changedAt()) {
+				result = l
+				stats.Local++
+			} else {
+				result = r
+				stats.Remote++
+			}
+		default:
+			secret := resolve(*l.secret, *r.secret)
+			result = entry{secret: &secret}
+			stats.Conflicts++
+		}
+
+		switch {
+		case result.secret != nil:
+			merged.Secrets = append(merged.Secrets, *result.secret)
+		case result.tombstone != nil:
+			merged.Deleted = append(merged.Deleted, *result.tombstone)
+		}
+	}
+
+	sort.Slice(merged.Deleted, func(i, j int) bool {
+		return merged.Deleted[i].Name < merged.Deleted[j].Name
+	})
+	return merged, stats
+}
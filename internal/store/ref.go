@@ -0,0 +1,95 @@
+package store
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ReferenceScheme is the URI scheme of secret references, e.g. vlxck://prod/db#password.
+const ReferenceScheme = "vlxck://"
+
+// Reference points at a field of a secret, written as "[vlxck://]secret[#field]".
+// The secret is given by its name or by its folder path and name, e.g. "prod/db"
+// for the secret "db" in the folder "prod".
+type Reference struct {
+	// Secret is the secret name, optionally prefixed with its folder path
+	Secret string
+	// Field is the field to read (see Secret.Lookup); "value" by default
+	Field string
+}
+
+// ParseReference parses a secret reference such as "stripe", "prod/db#username"
+// or "vlxck://prod/db#password".
+//
+// Parameters:
+//   - ref: The reference to parse
+//
+// Returns:
+//   - Reference: The parsed reference
+//   - error: An error if the reference does not name a secret
+func ParseReference(ref string) (Reference, error) {
+	spec := strings.TrimPrefix(strings.TrimSpace(ref), ReferenceScheme)
+	secret, field, _ := strings.Cut(spec, "#")
+	reference := Reference{Secret: strings.TrimSpace(secret), Field: strings.TrimSpace(field)}
+	if reference.Secret == "" {
+		return Reference{}, fmt.Errorf("invalid secret reference '%s'", ref)
+	}
+	if reference.Field == "" {
+		reference.Field = "value"
+	}
+	return reference, nil
+}
+
+// String returns the reference in URI form.
+func (r Reference) String() string {
+	return ReferenceScheme + r.Secret + "#" + r.Field
+}
+
+// Find returns the secret a reference points at. An exact name match wins;
+// otherwise the last path element is the name and the rest is the folder.
+//
+// Parameters:
+//   - name: The secret name, optionally prefixed with its folder path
+//
+// Returns:
+//   - *Secret: The secret, or nil if there is no such secret
+func (s *Store) Find(name string) *Secret {
+	for i := range s.Secrets {
+		if s.Secrets[i].Name == name {
+			return &s.Secrets[i]
+		}
+	}
+
+	folder, base := path.Split(name)
+	folder = NormalizeFolder(folder)
+	if folder == "" {
+		return nil
+	}
+	for i := range s.Secrets {
+		if s.Secrets[i].Name == base && s.Secrets[i].Category == folder {
+			return &s.Secrets[i]
+		}
+	}
+	return nil
+}
+
+// Resolve returns the value a reference points at.
+//
+// Parameters:
+//   - ref: The reference to resolve
+//
+// Returns:
+//   - string: The field value
+//   - error: An error if the secret or field does not exist
+func (s *Store) Resolve(ref Reference) (string, error) {
+	secret := s.Find(ref.Secret)
+	if secret == nil {
+		return "", fmt.Errorf("secret '%s' not found", ref.Secret)
+	}
+	value, ok := secret.Lookup(ref.Field)
+	if !ok {
+		return "", fmt.Errorf("field '%s' not found in secret '%s'", ref.Field, ref.Secret)
+	}
+	return value, nil
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/kirinyoku/vlxck/internal/crypto"
+)
+
+// Seal encrypts arbitrary data with the key, laid out like a store file:
+// [header][12-byte nonce][encrypted data]. Sealed data can be opened with
+// the password the key was derived from, just like the store itself.
+//
+// Parameters:
+//   - key: The key to encrypt with; its salt and parameters go into the header
+//   - data: The data to encrypt
+//
+// Returns:
+//   - []byte: The sealed data
+//   - error: Any error that occurred during encryption
+func Seal(key *Key, data []byte) ([]byte, error) {
+	encrypted, nonce, err := crypto.Encrypt(data, key.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	sealed := key.header().marshal()
+	sealed = append(sealed, nonce...)
+	return append(sealed, encrypted...), nil
+}
+
+// Open decrypts data sealed with Seal. The key is obtained from keyFor for
+// the header of the sealed data, so callers can reuse a cached key or derive
+// one from a password only when needed.
+//
+// Parameters:
+//   - data: The sealed data
+//   - keyFor: Returns the key for the header
+//
+// Returns:
+//   - []byte: The decrypted data
+//   - error: ErrKeyMismatch if keyFor returns a key for another header, or any
+//     error that occurred while parsing or decrypting the data
+func Open(data []byte, keyFor func(*Header) (*Key, error)) ([]byte, error) {
+	if !IsSealed(data) {
+		return nil, fmt.Errorf("data is not sealed")
+	}
+	header, nonce, encrypted, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keyFor(header)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Matches(header) {
+		return nil, ErrKeyMismatch
+	}
+	return crypto.Decrypt(encrypted, key.Bytes, nonce)
+}
+
+// IsSealed reports whether the data starts with a store header, i.e. was
+// written by Seal or is a store file with a versioned header.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// OpenStore decrypts store file data that is not read from the store path,
+// e.g. the store inside a backup. Like Open, the key is obtained from keyFor
+// for the header of the data; legacy headerless store files are accepted.
+//
+// Parameters:
+//   - data: The encrypted store file data
+//   - keyFor: Returns the key for the header
+//
+// Returns:
+//   - *Store: The decrypted store
+//   - error: ErrKeyMismatch if keyFor returns a key for another header, or any
+//     error that occurred during parsing, decryption or JSON unmarshaling
+func OpenStore(data []byte, keyFor func(*Header) (*Key, error)) (*Store, error) {
+	header, nonce, encrypted, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keyFor(header)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Matches(header) {
+		return nil, ErrKeyMismatch
+	}
+	return decryptStore(encrypted, key.Bytes, nonce)
+}
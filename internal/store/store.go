@@ -3,7 +3,6 @@
 package store
 
 import (
-	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -11,8 +10,15 @@ import (
 	"time"
 
 	"github.com/kirinyoku/vlxck/internal/crypto"
+	"github.com/kirinyoku/vlxck/internal/totp"
 )
 
+// CurrentVersion is the data schema version written by this version of vlxck.
+// Version 2 added usernames, URLs, notes, UpdatedAt and custom fields.
+// Version 3 turned categories into folder paths and added tags.
+// Version 4 added tombstones for deleted secrets.
+const CurrentVersion = 4
+
 // Store represents the main data structure for storing secrets.
 // It includes version information for backward compatibility
 // and a collection of secrets.
@@ -21,6 +27,8 @@ type Store struct {
 	Version int `json:"version"`
 	// Secrets is a collection of stored secret items
 	Secrets []Secret `json:"secrets"`
+	// Deleted records deleted secrets for merging copies of the store
+	Deleted []Tombstone `json:"deleted,omitempty"`
 }
 
 // Secret represents a single secret item with its metadata.
@@ -29,10 +37,26 @@ type Secret struct {
 	Name string `json:"name"`
 	// Value is the actual secret value (encrypted at rest)
 	Value string `json:"value"`
-	// Category helps in organizing secrets into groups
+	// Category is the folder path the secret is organized under, e.g. "work/aws/prod"
 	Category string `json:"category"`
+	// Tags are free-form labels used for filtering
+	Tags []string `json:"tags,omitempty"`
+	// Username is the login associated with the secret
+	Username string `json:"username,omitempty"`
+	// URLs lists the websites or endpoints the secret is used for
+	URLs []string `json:"urls,omitempty"`
+	// Notes holds free-form text about the secret
+	Notes string `json:"notes,omitempty"`
+	// Fields holds additional user-defined typed fields
+	Fields []CustomField `json:"fields,omitempty"`
+	// History holds previous values, most recent first (see MaxHistory)
+	History []HistoryEntry `json:"history,omitempty"`
+	// TOTP marks the value as a base32 TOTP seed and holds its parameters
+	TOTP *totp.Params `json:"totp,omitempty"`
 	// CreatedAt records when the secret was created
 	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt records when the secret was last modified
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // LoadStore reads and decrypts the store from the specified file.
@@ -46,8 +70,9 @@ type Secret struct {
 //   - *Store: Pointer to the loaded and decrypted store or an empty store if the file does not exist
 //   - error: Any error that occurred during file operations, decryption, or JSON unmarshaling
 //
-// Note: The file format is expected to be [16-byte salt][12-byte nonce][encrypted data].
-// The function uses AES-256-GCM for decryption with the provided password and stored salt.
+// Note: The file format is [header][12-byte nonce][encrypted data], where the header
+// records the KDF parameters and salt (see Header). Legacy files without a header,
+// laid out as [16-byte salt][12-byte nonce][encrypted data], are still accepted.
 func LoadStore(filePath, password string) (*Store, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -61,11 +86,15 @@ func LoadStore(filePath, password string) (*Store, error) {
 		return &Store{Secrets: []Secret{}}, fmt.Errorf("store file %s is empty", filePath)
 	}
 
-	salt := data[:16]
-	nonce := data[16:28]
-	encrypted := data[28:]
-	key := crypto.DeriveKey(password, salt)
+	header, nonce, encrypted, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	return decryptStore(encrypted, header.DeriveKey(password), nonce)
+}
 
+// decryptStore decrypts and unmarshals the store, upgrading it to CurrentVersion.
+func decryptStore(encrypted, key, nonce []byte) (*Store, error) {
 	plaintext, err := crypto.Decrypt(encrypted, key, nonce)
 	if err != nil {
 		return nil, err
@@ -76,11 +105,55 @@ func LoadStore(filePath, password string) (*Store, error) {
 		return nil, err
 	}
 
+	if err := store.migrate(); err != nil {
+		return nil, err
+	}
+
 	return &store, nil
 }
 
+// migrate upgrades a store loaded from disk to CurrentVersion.
+// The upgraded schema is persisted the next time the store is saved.
+func (s *Store) migrate() error {
+	if s.Version > CurrentVersion {
+		return fmt.Errorf("store version %d is newer than supported version %d", s.Version, CurrentVersion)
+	}
+
+	// Version 1 -> 2: new fields default to empty; UpdatedAt starts at CreatedAt
+	if s.Version < 2 {
+		for i := range s.Secrets {
+			if s.Secrets[i].UpdatedAt.IsZero() {
+				s.Secrets[i].UpdatedAt = s.Secrets[i].CreatedAt
+			}
+		}
+		s.Version = 2
+	}
+
+	// Version 2 -> 3: categories are folder paths, normalize them
+	if s.Version < 3 {
+		for i := range s.Secrets {
+			s.Secrets[i].Category = NormalizeFolder(s.Secrets[i].Category)
+		}
+		s.Version = 3
+	}
+
+	// Version 3 -> 4: no secrets have been deleted with tombstones yet
+	if s.Version < 4 {
+		s.Version = 4
+	}
+
+	if s.Secrets == nil {
+		s.Secrets = []Secret{}
+	}
+	return nil
+}
+
 // SaveStore encrypts and writes the store to the specified file.
-// If the file exists, it reuses the existing salt; otherwise, it generates a new one.
+// If the file exists, it reuses the existing salt and KDF parameters;
+// otherwise, it generates a new salt with DefaultKDFParams. A file whose
+// header cannot be read (unsupported version, truncated or corrupt) is an
+// error rather than being rewritten with new parameters.
+// Legacy headerless files are rewritten with a versioned header.
 //
 // Parameters:
 //   - filePath: Path where the store should be saved
@@ -90,21 +163,37 @@ func LoadStore(filePath, password string) (*Store, error) {
 // Returns:
 //   - error: Any error that occurred during file operations, encryption, or JSON marshaling
 //
-// Note: The file format is [16-byte salt][12-byte nonce][encrypted data].
+// Note: The file format is [header][12-byte nonce][encrypted data].
 // The function creates any necessary parent directories with 0700 permissions.
 // The file is saved with 0600 permissions for security.
 func SaveStore(filePath, password string, store *Store) error {
-	var salt []byte
-	if _, err := os.Stat(filePath); err == nil {
-		data, _ := os.ReadFile(filePath)
-		salt = data[:16]
-	} else {
-		salt = make([]byte, 16)
-		if _, err := rand.Read(salt); err != nil {
-			return err
-		}
+	header, err := ReadHeader(filePath)
+	if os.IsNotExist(err) {
+		header, err = NewHeader(crypto.DefaultKDFParams)
+	}
+	if err != nil {
+		return err
 	}
-	key := crypto.DeriveKey(password, salt)
+	return SaveStoreWithHeader(filePath, password, store, header)
+}
+
+// SaveStoreWithHeader encrypts and writes the store using the salt and KDF
+// parameters of the given header instead of those found on disk.
+//
+// Parameters:
+//   - filePath: Path where the store should be saved
+//   - password: Password used for encryption
+//   - store: Pointer to the Store struct to be saved
+//   - header: Header describing how the key is derived
+//
+// Returns:
+//   - error: Any error that occurred during file operations, encryption, or JSON marshaling
+func SaveStoreWithHeader(filePath, password string, store *Store, header *Header) error {
+	return writeStore(filePath, header, header.DeriveKey(password), store)
+}
+
+// writeStore encrypts the store with the key and writes it after the header.
+func writeStore(filePath string, header *Header, key []byte, store *Store) error {
 	plaintext, _ := json.Marshal(store)
 	encrypted, nonce, err := crypto.Encrypt(plaintext, key)
 	if err != nil {
@@ -112,13 +201,14 @@ func SaveStore(filePath, password string, store *Store) error {
 	}
 	dir := filepath.Dir(filePath)
 	os.MkdirAll(dir, 0700)
-	data := append(salt, nonce...)
+	data := header.marshal()
+	data = append(data, nonce...)
 	data = append(data, encrypted...)
 	return os.WriteFile(filePath, data, 0600)
 }
 
 // InitializeStore creates a new, empty store file with default settings.
-// It generates a new random salt and initializes the store with version 1.
+// It generates a new random salt and initializes the store with CurrentVersion.
 //
 // Parameters:
 //   - filePath: Path where the new store should be created
@@ -127,14 +217,14 @@ func SaveStore(filePath, password string, store *Store) error {
 // Returns:
 //   - error: Any error that occurred during store creation or initialization
 //
-// Note: This function creates a new store with an empty secrets slice and version 1.
-// The store is immediately saved to disk using SaveStore with the provided password.
+// Note: This function creates a new store with an empty secrets slice and CurrentVersion.
+// The store is immediately saved to disk using SaveStoreWithHeader with the provided password.
 // The salt is randomly generated using crypto/rand for secure key derivation.
 func InitializeStore(filePath, password string) error {
-	salt := make([]byte, 16)
-	if _, err := rand.Read(salt); err != nil {
+	header, err := NewHeader(crypto.DefaultKDFParams)
+	if err != nil {
 		return err
 	}
-	store := &Store{Version: 1, Secrets: []Secret{}}
-	return SaveStore(filePath, password, store)
+	store := &Store{Version: CurrentVersion, Secrets: []Secret{}}
+	return SaveStoreWithHeader(filePath, password, store, header)
 }
@@ -0,0 +1,375 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/utils"
+)
+
+// ProviderGit is the name of the git provider in the config.
+const ProviderGit = "git"
+
+// Defaults for the git provider.
+const (
+	gitDefaultBranch = "main"
+	gitRemote        = "origin"
+	gitStoreFile     = "store.dat"
+)
+
+func init() {
+	Register(ProviderGit, NewGitSync)
+}
+
+// GitSync implements synchronization through a git remote using the system
+// git. The encrypted store is committed into a local repository in
+// ~/.vlxck/git, one commit per change, which gives a full history of the
+// store. Only the encrypted blob is committed, and commit messages never
+// name secrets.
+//
+// Git never merges the blob itself: when local and remote histories have
+// diverged, Push reports ErrRemoteChanged and Pull leaves the merge of the
+// store to the secret-level merge of SyncManager.
+type GitSync struct {
+	config   *config.Config
+	ctx      context.Context
+	dir      string // Local repository
+	noPrompt bool   // Whether git must fail instead of asking for credentials
+}
+
+// NewGitSync creates a new git synchronizer instance
+func NewGitSync(ctx context.Context, cfg *config.Config, masterPassword string) (Provider, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find home directory: %v", err)
+	}
+	return &GitSync{
+		config: cfg,
+		ctx:    ctx,
+		dir:    filepath.Join(homeDir, ".vlxck", "git"),
+	}, nil
+}
+
+// Init prompts for the remote URL and branch, creates the local repository
+// and checks that the remote can be reached.
+func (g *GitSync) Init() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git is not installed: %v", err)
+	}
+	settings := &g.config.Sync.Git
+
+	remote, err := utils.PromptForInput("Git remote URL", settings.Remote, notEmpty("remote URL"))
+	if err != nil {
+		return err
+	}
+	branch := settings.Branch
+	if branch == "" {
+		branch = gitDefaultBranch
+	}
+	branch, err = utils.PromptForInput("Branch", branch, func(input string) error {
+		if input == "" {
+			return fmt.Errorf("branch cannot be empty")
+		}
+		return exec.CommandContext(g.ctx, "git", "check-ref-format", "--branch", input).Run()
+	})
+	if err != nil {
+		return err
+	}
+	settings.Remote, settings.Branch = remote, branch
+
+	if err := g.ensureRepo(); err != nil {
+		return err
+	}
+	if _, err := g.git("remote", "get-url", gitRemote); err != nil {
+		_, err = g.git("remote", "add", gitRemote, remote)
+		if err != nil {
+			return err
+		}
+	} else if _, err := g.git("remote", "set-url", gitRemote, remote); err != nil {
+		return err
+	}
+
+	exists, err := g.fetch()
+	if err != nil {
+		return err
+	}
+	if exists {
+		fmt.Println("Found an existing remote store. Pull it before pushing local changes.")
+	} else {
+		fmt.Println("No remote store found. The first push will create it.")
+	}
+
+	// The ETag of a previous provider means nothing here
+	g.config.Sync.Etag = ""
+	return nil
+}
+
+// Record commits the store file to the local repository if it changed.
+// It does nothing until the repository has been created by Init.
+func (g *GitSync) Record(storePath, message string) error {
+	if !g.initialized() {
+		return nil
+	}
+	_, err := g.commit(storePath, message)
+	return err
+}
+
+// Push commits pending changes and pushes them. The push is refused with
+// ErrRemoteChanged if the remote branch has commits that are not in the
+// local history.
+func (g *GitSync) Push(storePath string) (*Metadata, error) {
+	if err := g.ready(); err != nil {
+		return nil, err
+	}
+	if _, err := g.commit(storePath, "vlxck sync"); err != nil {
+		return nil, err
+	}
+
+	exists, err := g.fetch()
+	if err != nil {
+		return nil, err
+	}
+	if exists && !g.isAncestor(g.remoteRef(), "HEAD") {
+		return nil, ErrRemoteChanged
+	}
+
+	if _, err := g.git("push", gitRemote, "HEAD:refs/heads/"+g.config.Sync.Git.Branch); err != nil {
+		// Someone pushed between our fetch and push
+		if strings.Contains(err.Error(), "rejected") {
+			return nil, ErrRemoteChanged
+		}
+		return nil, err
+	}
+	return g.commitMetadata("HEAD")
+}
+
+// Pull fetches the remote branch and writes the remote store to storePath.
+// The local repository is fast-forwarded when it has no commits of its own.
+// If both sides have new commits, the histories are joined with a merge
+// commit that keeps the local store: git never merges the store itself,
+// the caller merges it secret by secret and records the result.
+func (g *GitSync) Pull(storePath string) (*Metadata, error) {
+	if err := g.ready(); err != nil {
+		return nil, err
+	}
+
+	exists, err := g.fetch()
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNoRemote
+	}
+
+	remote := g.remoteRef()
+	switch {
+	case !g.hasHead() || g.isAncestor("HEAD", remote):
+		if _, err := g.git("merge", "--quiet", "--ff-only", remote); err != nil {
+			return nil, err
+		}
+	case !g.isAncestor(remote, "HEAD"):
+		if _, err := g.git("merge", "--quiet", "--no-edit", "--strategy=ours", "--allow-unrelated-histories", "-m", "vlxck merge", remote); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := g.gitBytes("show", remote+":"+gitStoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote store: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(storePath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %v", err)
+	}
+	if err := utils.WriteFileAtomic(storePath, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write store: %v", err)
+	}
+	return g.commitMetadata(remote)
+}
+
+// Metadata fetches the remote branch and returns its latest commit
+func (g *GitSync) Metadata() (*Metadata, error) {
+	if err := g.ready(); err != nil {
+		return nil, err
+	}
+	exists, err := g.fetch()
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNoRemote
+	}
+	return g.commitMetadata(g.remoteRef())
+}
+
+// ensureRepo creates the local repository
+func (g *GitSync) ensureRepo() error {
+	if g.initialized() {
+		return nil
+	}
+	if err := os.MkdirAll(g.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create repository directory: %v", err)
+	}
+	if _, err := g.git("init", "--quiet"); err != nil {
+		return err
+	}
+	if _, err := g.git("symbolic-ref", "HEAD", "refs/heads/"+g.config.Sync.Git.Branch); err != nil {
+		return err
+	}
+
+	// Commits need an identity; don't depend on the user's global git config
+	if email, _ := g.git("config", "user.email"); email == "" {
+		if _, err := g.git("config", "user.email", "vlxck@localhost"); err != nil {
+			return err
+		}
+		if _, err := g.git("config", "user.name", "vlxck"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commit copies the store file into the repository and commits it if it
+// changed. It reports whether a commit was made.
+func (g *GitSync) commit(storePath, message string) (bool, error) {
+	data, err := os.ReadFile(storePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read store: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(g.dir, gitStoreFile), data, 0600); err != nil {
+		return false, fmt.Errorf("failed to write store to repository: %v", err)
+	}
+	// Mark the store as binary so git never tries to diff or merge it
+	if err := os.WriteFile(filepath.Join(g.dir, ".gitattributes"), []byte(gitStoreFile+" binary\n"), 0600); err != nil {
+		return false, fmt.Errorf("failed to write .gitattributes: %v", err)
+	}
+
+	if _, err := g.git("add", "--", gitStoreFile, ".gitattributes"); err != nil {
+		return false, err
+	}
+	// Exit status 1 means there are staged changes
+	if _, err := g.git("diff", "--cached", "--quiet"); err == nil {
+		return false, nil
+	}
+	if _, err := g.git("commit", "--quiet", "--no-verify", "-m", message); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// fetch updates the remote-tracking branch and reports whether the remote
+// branch exists.
+func (g *GitSync) fetch() (bool, error) {
+	if _, err := g.git("fetch", "--quiet", "--prune", gitRemote); err != nil {
+		return false, err
+	}
+	_, err := g.git("rev-parse", "--verify", "--quiet", g.remoteRef())
+	return err == nil, nil
+}
+
+// commitMetadata describes a commit: its hash serves as ETag and version
+func (g *GitSync) commitMetadata(rev string) (*Metadata, error) {
+	out, err := g.git("log", "-1", "--format=%H %cI", rev)
+	if err != nil {
+		return nil, err
+	}
+	hash, date, _ := strings.Cut(out, " ")
+	meta := &Metadata{ETag: hash, Version: hash}
+	if committed, err := time.Parse(time.RFC3339, date); err == nil {
+		meta.ModifiedAt = committed
+	}
+	return meta, nil
+}
+
+// isAncestor reports whether the first revision is an ancestor of the second
+func (g *GitSync) isAncestor(ancestor, rev string) bool {
+	_, err := g.git("merge-base", "--is-ancestor", ancestor, rev)
+	return err == nil
+}
+
+// hasHead reports whether the local branch has any commits
+func (g *GitSync) hasHead() bool {
+	_, err := g.git("rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
+
+// remoteRef returns the remote-tracking branch
+func (g *GitSync) remoteRef() string {
+	return "refs/remotes/" + gitRemote + "/" + g.config.Sync.Git.Branch
+}
+
+// initialized reports whether the local repository exists
+func (g *GitSync) initialized() bool {
+	_, err := os.Stat(filepath.Join(g.dir, ".git"))
+	return err == nil
+}
+
+// ready checks that Init has been run
+func (g *GitSync) ready() error {
+	if g.config.Sync.Git.Remote == "" || !g.initialized() {
+		return fmt.Errorf("git sync is not initialized; run 'vlxck sync --init --provider git' first")
+	}
+	return nil
+}
+
+// NoPrompt makes git fail instead of asking for credentials of the remote or
+// for an SSH passphrase, and keeps it from reading the terminal.
+func (g *GitSync) NoPrompt() {
+	g.noPrompt = true
+}
+
+// noPromptEnv returns the environment for git commands that must not prompt:
+// terminal and askpass prompts are off and SSH runs in batch mode.
+func noPromptEnv() []string {
+	sshCommand := os.Getenv("GIT_SSH_COMMAND")
+	if sshCommand == "" {
+		sshCommand = "ssh"
+	}
+	return append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_ASKPASS=",
+		"SSH_ASKPASS=",
+		"GIT_SSH_COMMAND="+sshCommand+" -o BatchMode=yes",
+	)
+}
+
+// git runs a git command in the local repository and returns its trimmed
+// output. The error includes git's error message.
+func (g *GitSync) git(args ...string) (string, error) {
+	out, err := g.gitBytes(args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// gitBytes runs a git command in the local repository and returns its raw output
+func (g *GitSync) gitBytes(args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(g.ctx, "git", args...)
+	cmd.Dir = g.dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if g.noPrompt {
+		cmd.Env = noPromptEnv()
+	} else {
+		// Credential prompts of remote helpers read from the terminal
+		cmd.Stdin = os.Stdin
+	}
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
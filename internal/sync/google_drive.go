@@ -20,12 +20,19 @@ import (
 	"google.golang.org/api/option"
 )
 
+// ProviderGoogleDrive is the name of the Google Drive provider in the config.
+const ProviderGoogleDrive = "google_drive"
+
+func init() {
+	Register(ProviderGoogleDrive, NewGoogleDriveSync)
+}
+
 // GoogleDriveSync implements synchronization with Google Drive
 type GoogleDriveSync struct {
-	client *drive.Service
-	config *config.Config
-	token  *oauth2.Token
-	ctx    context.Context
+	client         *drive.Service // Created on first use by connect
+	config         *config.Config
+	masterPassword string
+	ctx            context.Context
 }
 
 // validateClientIDFormat validates the format of the Client ID
@@ -107,25 +114,34 @@ func getClientCredentials(cfg *config.Config, masterPassword string) (string, st
 	}
 }
 
-// NewGoogleDriveSync creates a new synchronizer instance
-func NewGoogleDriveSync(ctx context.Context, masterPassword string) (*GoogleDriveSync, error) {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %v", err)
-	}
+// NewGoogleDriveSync creates a new synchronizer instance. The connection to
+// Google Drive is only established when it is first needed, so that Init can
+// run before any credentials are configured.
+func NewGoogleDriveSync(ctx context.Context, cfg *config.Config, masterPassword string) (Provider, error) {
+	return &GoogleDriveSync{
+		config:         cfg,
+		masterPassword: masterPassword,
+		ctx:            ctx,
+	}, nil
+}
 
-	if cfg.Sync.Provider != "google_drive" && cfg.Sync.Provider != "" {
-		return nil, fmt.Errorf("google_drive provider not configured")
+// connect creates the Drive client from the stored credentials and token
+func (g *GoogleDriveSync) connect() error {
+	if g.client != nil {
+		return nil
+	}
+	if len(g.config.Sync.EncryptedToken) == 0 || g.config.Sync.FileID == "" {
+		return fmt.Errorf("Google Drive sync is not initialized; run 'vlxck sync --init' first")
 	}
 
-	token, err := config.DecryptToken(cfg.Sync.EncryptedToken, masterPassword)
-	if err != nil && len(cfg.Sync.EncryptedToken) > 0 {
-		return nil, fmt.Errorf("failed to decrypt token: %v", err)
+	token, err := config.DecryptToken(g.config.Sync.EncryptedToken, g.masterPassword)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt token: %v", err)
 	}
 
-	clientID, clientSecret, err := getClientCredentials(cfg, masterPassword)
+	clientID, clientSecret, err := getClientCredentials(g.config, g.masterPassword)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get client credentials: %v", err)
+		return fmt.Errorf("failed to get client credentials: %v", err)
 	}
 
 	oauthConfig := &oauth2.Config{
@@ -136,36 +152,25 @@ func NewGoogleDriveSync(ctx context.Context, masterPassword string) (*GoogleDriv
 		Endpoint:     google.Endpoint,
 	}
 
-	var client *http.Client
-	if token != nil {
-		client = oauthConfig.Client(ctx, token)
-	} else {
-		client = &http.Client{}
-	}
-
-	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	client := oauthConfig.Client(g.ctx, token)
+	driveService, err := drive.NewService(g.ctx, option.WithHTTPClient(client))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create drive service: %v", err)
+		return fmt.Errorf("failed to create drive service: %v", err)
 	}
 
-	return &GoogleDriveSync{
-		client: driveService,
-		config: cfg,
-		token:  token,
-		ctx:    ctx,
-	}, nil
+	g.client = driveService
+	return nil
 }
 
-// InitGoogleDrive initializes synchronization
-func InitGoogleDrive(ctx context.Context, masterPassword string) (*config.Config, error) {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %v", err)
-	}
+// Init authorizes vlxck to access Google Drive and finds or creates the
+// remote store.dat. The encrypted credentials and the file ID are stored in
+// the config.
+func (g *GoogleDriveSync) Init() error {
+	ctx, cfg, masterPassword := g.ctx, g.config, g.masterPassword
 
 	clientID, clientSecret, err := getClientCredentials(cfg, masterPassword)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get client credentials: %v", err)
+		return fmt.Errorf("failed to get client credentials: %v", err)
 	}
 
 	oauthConfig := &oauth2.Config{
@@ -220,30 +225,29 @@ func InitGoogleDrive(ctx context.Context, masterPassword string) (*config.Config
 		// Save credentials only after successful authorization
 		encryptedClientID, encryptedClientSecret, err := config.EncryptClientCredentials(clientID, clientSecret, masterPassword)
 		if err != nil {
-			return nil, fmt.Errorf("failed to encrypt client credentials: %v", err)
+			return fmt.Errorf("failed to encrypt client credentials: %v", err)
 		}
 		cfg.Sync.EncryptedClientId = encryptedClientID
 		cfg.Sync.EncryptedClientSecret = encryptedClientSecret
 
 		encryptedToken, err := config.EncryptToken(token, masterPassword)
 		if err != nil {
-			return nil, fmt.Errorf("failed to encrypt token: %v", err)
+			return fmt.Errorf("failed to encrypt token: %v", err)
 		}
 
-		cfg.Sync.Provider = "google_drive"
 		cfg.Sync.EncryptedToken = encryptedToken
 
 		client := oauthConfig.Client(ctx, token)
 		driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
 		if err != nil {
-			return nil, fmt.Errorf("failed to create drive service: %v", err)
+			return fmt.Errorf("failed to create drive service: %v", err)
 		}
 
 		fmt.Println("Searching for existing store.dat in Google Drive...")
 		query := "name='store.dat' and 'root' in parents and trashed=false"
 		files, err := driveService.Files.List().Q(query).Spaces("drive").Fields("files(id, name, size)").Do()
 		if err != nil {
-			return nil, fmt.Errorf("failed to list files in Google Drive: %v", err)
+			return fmt.Errorf("failed to list files in Google Drive: %v", err)
 		}
 
 		var fileId string
@@ -259,97 +263,87 @@ func InitGoogleDrive(ctx context.Context, masterPassword string) (*config.Config
 			}
 			createdFile, err := driveService.Files.Create(file).Media(bytes.NewReader([]byte{})).Do()
 			if err != nil {
-				return nil, fmt.Errorf("failed to create file: %v", err)
+				return fmt.Errorf("failed to create file: %v", err)
 			}
 			fileId = createdFile.Id
 			fmt.Printf("Created new store.dat with FileID: %s\n", fileId)
 		}
 		cfg.Sync.FileID = fileId
 
-		fmt.Printf("Saving config with FileID: %s, Provider: %s\n", cfg.Sync.FileID, cfg.Sync.Provider)
-		if err := config.SaveConfig(cfg); err != nil {
-			return nil, fmt.Errorf("failed to save config: %v", err)
-		}
-
 		if err := server.Shutdown(ctx); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to shutdown OAuth server: %v\n", err)
 		}
-		return cfg, nil
+		g.client = driveService
+		return nil
 	case err := <-errChan:
 		if err := server.Shutdown(ctx); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to shutdown OAuth server: %v\n", err)
 		}
-		return nil, err
+		return err
 	}
 }
 
 // Push uploads the local store.dat to Google Drive
-func (g *GoogleDriveSync) Push(storePath string) error {
+func (g *GoogleDriveSync) Push(storePath string) (*Metadata, error) {
+	if err := g.connect(); err != nil {
+		return nil, err
+	}
+
 	data, err := os.Open(storePath)
 	if err != nil {
-		return fmt.Errorf("failed to read store: %v", err)
+		return nil, fmt.Errorf("failed to read store: %v", err)
 	}
 	defer data.Close()
 
 	_, err = g.client.Files.Update(g.config.Sync.FileID, nil).Media(data).Do()
 	if err != nil {
-		return fmt.Errorf("failed to upload file: %v", err)
+		return nil, fmt.Errorf("failed to upload file: %v", err)
 	}
 
-	file, err := g.client.Files.Get(g.config.Sync.FileID).Fields("id, modifiedTime, md5Checksum").Do()
-	if err != nil {
-		return fmt.Errorf("failed to get file metadata: %v", err)
-	}
-	g.config.Sync.Etag = file.Md5Checksum
-	if err := config.SaveConfig(g.config); err != nil {
-		return fmt.Errorf("failed to save config: %v", err)
-	}
-
-	return nil
+	return g.Metadata()
 }
 
 // Pull downloads store.dat from Google Drive
-func (g *GoogleDriveSync) Pull(storePath string) error {
+func (g *GoogleDriveSync) Pull(storePath string) (*Metadata, error) {
+	if err := g.connect(); err != nil {
+		return nil, err
+	}
+
 	resp, err := g.client.Files.Get(g.config.Sync.FileID).Download()
 	if err != nil {
-		return fmt.Errorf("failed to download file: %v", err)
+		return nil, fmt.Errorf("failed to download file: %v", err)
 	}
 	defer resp.Body.Close()
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %v", err)
+		return nil, fmt.Errorf("failed to read file: %v", err)
 	}
 
 	if err := os.MkdirAll(filepath.Dir(storePath), 0755); err != nil {
-		return fmt.Errorf("failed to create store directory: %v", err)
+		return nil, fmt.Errorf("failed to create store directory: %v", err)
 	}
 
 	if err := os.WriteFile(storePath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write store: %v", err)
-	}
-
-	file, err := g.client.Files.Get(g.config.Sync.FileID).Fields("id, modifiedTime, md5Checksum").Do()
-	if err != nil {
-		return fmt.Errorf("failed to get file metadata: %v", err)
-	}
-	g.config.Sync.Etag = file.Md5Checksum
-	if err := config.SaveConfig(g.config); err != nil {
-		return fmt.Errorf("failed to save config: %v", err)
+		return nil, fmt.Errorf("failed to write store: %v", err)
 	}
 
-	return nil
+	return g.Metadata()
 }
 
-// GetMetadata returns file metadata
-func (g *GoogleDriveSync) GetMetadata() (string, time.Time, error) {
+// Metadata returns the checksum and modification time of the remote store.dat
+func (g *GoogleDriveSync) Metadata() (*Metadata, error) {
+	if err := g.connect(); err != nil {
+		return nil, err
+	}
+
 	file, err := g.client.Files.Get(g.config.Sync.FileID).Fields("id, modifiedTime, md5Checksum").Do()
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("failed to get file metadata: %v", err)
+		return nil, fmt.Errorf("failed to get file metadata: %v", err)
 	}
 	modifiedTime, err := time.Parse(time.RFC3339, file.ModifiedTime)
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("failed to parse modified time: %v", err)
+		return nil, fmt.Errorf("failed to parse modified time: %v", err)
 	}
-	return file.Md5Checksum, modifiedTime, nil
+	return &Metadata{ETag: file.Md5Checksum, ModifiedAt: modifiedTime}, nil
 }
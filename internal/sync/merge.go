@@ -0,0 +1,209 @@
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/utils"
+)
+
+// baseFile is the name of the snapshot of the store as of the last sync,
+// kept next to the store. It is the common ancestor for merging.
+const baseFile = "sync.base"
+
+// basePath returns the path of the sync base snapshot for the store
+func basePath(storePath string) string {
+	return filepath.Join(filepath.Dir(storePath), baseFile)
+}
+
+// saveBase records the store file as the base for the next merge
+func saveBase(storePath string) error {
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		return fmt.Errorf("failed to read store: %v", err)
+	}
+	if err := utils.WriteFileAtomic(basePath(storePath), data, 0600); err != nil {
+		return fmt.Errorf("failed to save sync base: %v", err)
+	}
+	return nil
+}
+
+// localChanged reports whether the store changed since the last sync. It
+// returns false for the second value if the store was never synced.
+func localChanged(storePath string) (changed, synced bool, err error) {
+	base, err := os.ReadFile(basePath(storePath))
+	if os.IsNotExist(err) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to read sync base: %v", err)
+	}
+	local, err := os.ReadFile(storePath)
+	if err != nil {
+		return false, true, fmt.Errorf("failed to read store: %v", err)
+	}
+	return !bytes.Equal(local, base), true, nil
+}
+
+// removeBase forgets the sync base, e.g. when a new remote is configured
+func removeBase(storePath string) error {
+	if err := os.Remove(basePath(storePath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove sync base: %v", err)
+	}
+	return nil
+}
+
+// pull downloads the remote store and merges it into the local store. Only
+// the secrets changed on one side are taken from that side; secrets changed
+// differently on both sides since the last sync are resolved by the user, or
+// fail the pull with ErrConflicts if the user cannot be asked.
+func (s *SyncManager) pull() (*Metadata, error) {
+	local, err := os.ReadFile(s.storePath)
+	if os.IsNotExist(err) {
+		// Nothing to merge with
+		meta, err := s.provider.Pull(s.storePath)
+		if err != nil {
+			return nil, err
+		}
+		return meta, saveBase(s.storePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store: %v", err)
+	}
+
+	remotePath := s.storePath + ".remote"
+	defer os.Remove(remotePath)
+	meta, err := s.provider.Pull(remotePath)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := os.ReadFile(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote store: %v", err)
+	}
+	base, err := os.ReadFile(basePath(s.storePath))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read sync base: %v", err)
+	}
+
+	switch {
+	case bytes.Equal(local, remote), bytes.Equal(remote, base):
+		// Nothing new on the remote; keep the local store
+	case bytes.Equal(local, base):
+		// Nothing new locally; take the remote store as is
+		if err := utils.WriteFileAtomic(s.storePath, remote, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write store: %v", err)
+		}
+	default:
+		if err := s.merge(remotePath, base != nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// Providers keeping a local history record the merged store
+	if recorder, ok := s.provider.(Recorder); ok {
+		if err := recorder.Record(s.storePath, "vlxck sync"); err != nil {
+			return nil, err
+		}
+	}
+
+	// The remote store is now the common ancestor of both sides
+	if err := utils.WriteFileAtomic(basePath(s.storePath), remote, 0600); err != nil {
+		return nil, fmt.Errorf("failed to save sync base: %v", err)
+	}
+	return meta, nil
+}
+
+// merge decrypts the local, remote and base stores and writes the merged
+// store to the local store file, keeping its encryption header.
+func (s *SyncManager) merge(remotePath string, haveBase bool) error {
+	key, err := s.storeKey()
+	if err != nil {
+		return err
+	}
+	if key != s.key {
+		defer key.Wipe()
+	}
+
+	local, err := store.LoadStoreWithKey(s.storePath, key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt local store: %v", err)
+	}
+	remote, err := s.load(remotePath, key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt remote store: %v", err)
+	}
+
+	var base *store.Store
+	if haveBase {
+		base, err = s.load(basePath(s.storePath), key)
+		if err != nil {
+			// Merging without a base is safe, it just asks about more conflicts
+			fmt.Fprintf(os.Stderr, "Warning: Failed to decrypt the store of the last sync, merging without it: %v\n", err)
+			base = nil
+		}
+	}
+
+	// Conflicts that cannot be resolved keep the local version and abort the
+	// merge before anything is written
+	var conflictErr error
+	merged, stats := store.Merge(base, local, remote, func(l, r store.Secret) store.Secret {
+		if conflictErr != nil {
+			return l
+		}
+		if !s.prompt {
+			conflictErr = ErrConflicts
+			return l
+		}
+		useRemote, err := utils.PromptForSyncConflict(l, r)
+		if err != nil {
+			conflictErr = fmt.Errorf("%w: %v", ErrConflicts, err)
+			return l
+		}
+		if useRemote {
+			return r
+		}
+		return l
+	})
+	if conflictErr != nil {
+		return conflictErr
+	}
+
+	if err := store.SaveStoreWithKey(s.storePath, key, merged); err != nil {
+		return fmt.Errorf("failed to save merged store: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Merged %d local and %d remote changes (%d conflicts).\n", stats.Local, stats.Remote, stats.Conflicts)
+	return nil
+}
+
+// storeKey returns the key of the local store: the key given with UseKey if
+// it still matches the store, otherwise one derived from the master password.
+func (s *SyncManager) storeKey() (*store.Key, error) {
+	header, err := store.ReadHeader(s.storePath)
+	if err != nil {
+		return nil, err
+	}
+	if s.key != nil && s.key.Matches(header) {
+		return s.key, nil
+	}
+	if s.masterPassword == "" {
+		return nil, fmt.Errorf("the master password is needed to merge the local and remote stores")
+	}
+	return header.Key(s.masterPassword), nil
+}
+
+// load decrypts a store file, reusing the key if the file has the same
+// header as the local store and deriving a new one from the master
+// password otherwise (e.g. after the password was changed).
+func (s *SyncManager) load(path string, key *store.Key) (*store.Store, error) {
+	st, err := store.LoadStoreWithKey(path, key)
+	if errors.Is(err, store.ErrKeyMismatch) {
+		return store.LoadStore(path, s.masterPassword)
+	}
+	return st, err
+}
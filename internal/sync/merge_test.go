@@ -0,0 +1,151 @@
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/store"
+)
+
+// memProvider keeps the remote copy of the store in memory.
+type memProvider struct {
+	data []byte
+}
+
+func (m *memProvider) Init() error { return nil }
+
+func (m *memProvider) Push(storePath string) (*Metadata, error) {
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		return nil, err
+	}
+	m.data = data
+	return &Metadata{ETag: "pushed"}, nil
+}
+
+func (m *memProvider) Pull(storePath string) (*Metadata, error) {
+	if m.data == nil {
+		return nil, ErrNoRemote
+	}
+	return &Metadata{ETag: "remote"}, os.WriteFile(storePath, m.data, 0600)
+}
+
+func (m *memProvider) Metadata() (*Metadata, error) {
+	if m.data == nil {
+		return nil, ErrNoRemote
+	}
+	return &Metadata{ETag: "remote"}, nil
+}
+
+// newMergeTest creates a synced store holding one secret and returns a
+// manager that cannot prompt, its remote and the store key.
+func newMergeTest(t *testing.T) (*SyncManager, *memProvider, *store.Key) {
+	t.Helper()
+	storePath := filepath.Join(t.TempDir(), "store.dat")
+	if err := store.InitializeStore(storePath, testMasterPassword); err != nil {
+		t.Fatal(err)
+	}
+	key, err := store.DeriveKey(storePath, testMasterPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updateStore(t, storePath, key, func(s *store.Store) {
+		s.Secrets = append(s.Secrets, store.Secret{Name: "shared", Value: "base", UpdatedAt: time.Now()})
+	})
+	if err := saveBase(storePath); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := &memProvider{data: data}
+	manager := &SyncManager{storePath: storePath, key: key, config: &config.Config{}, provider: remote}
+	return manager, remote, key
+}
+
+// updateStore changes the store file with the key.
+func updateStore(t *testing.T, path string, key *store.Key, change func(*store.Store)) {
+	t.Helper()
+	s, err := store.LoadStoreWithKey(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change(s)
+	if err := store.SaveStoreWithKey(path, key, s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// updateRemote changes the remote copy of the store.
+func updateRemote(t *testing.T, remote *memProvider, key *store.Key, change func(*store.Store)) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "remote.dat")
+	if err := os.WriteFile(path, remote.data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	updateStore(t, path, key, change)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote.data = data
+}
+
+// setValue returns a change that sets the value of the shared secret.
+func setValue(value string) func(*store.Store) {
+	return func(s *store.Store) {
+		s.Secrets[0].SetValue(value)
+		s.Secrets[0].UpdatedAt = time.Now()
+	}
+}
+
+func TestPullConflictWithoutPrompt(t *testing.T) {
+	manager, remote, key := newMergeTest(t)
+	updateStore(t, manager.storePath, key, setValue("local"))
+	updateRemote(t, remote, key, setValue("remote"))
+
+	before, err := os.ReadFile(manager.storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.pull(); !errors.Is(err, ErrConflicts) {
+		t.Fatalf("pull: err = %v, want ErrConflicts", err)
+	}
+	after, err := os.ReadFile(manager.storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("local store was changed by a pull with unresolved conflicts")
+	}
+}
+
+func TestPullMergesWithoutConflicts(t *testing.T) {
+	manager, remote, key := newMergeTest(t)
+	updateStore(t, manager.storePath, key, func(s *store.Store) {
+		s.Secrets = append(s.Secrets, store.Secret{Name: "local-only", Value: "l", UpdatedAt: time.Now()})
+	})
+	updateRemote(t, remote, key, setValue("remote"))
+
+	if _, err := manager.pull(); err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	merged, err := store.LoadStoreWithKey(manager.storePath, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]string{}
+	for _, secret := range merged.Secrets {
+		values[secret.Name] = secret.Value
+	}
+	if values["shared"] != "remote" || values["local-only"] != "l" {
+		t.Errorf("merged secrets = %v", values)
+	}
+}
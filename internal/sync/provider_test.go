@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+)
+
+// testMasterPassword encrypts the credentials in test configs.
+const testMasterPassword = "master"
+
+// fakeObject is the single remote copy of the store held by a fake server.
+// The protocol-specific fakes embed it.
+type fakeObject struct {
+	data    []byte
+	etag    string
+	version int
+}
+
+// serveRead answers a HEAD or GET request with the object.
+func (o *fakeObject) serveRead(w http.ResponseWriter, r *http.Request) {
+	if o.data == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("ETag", o.etag)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		w.Write(o.data)
+	}
+}
+
+// preconditionFailed reports whether If-None-Match: * or If-Match of a PUT
+// request rule out replacing the object.
+func (o *fakeObject) preconditionFailed(r *http.Request) bool {
+	if r.Header.Get("If-None-Match") == "*" && o.data != nil {
+		return true
+	}
+	match := r.Header.Get("If-Match")
+	return match != "" && (o.data == nil || match != o.etag)
+}
+
+// replace stores the body of a PUT request as a new version of the object,
+// with an ETag made from the version by etagFormat.
+func (o *fakeObject) replace(r *http.Request, etagFormat string) {
+	data, _ := io.ReadAll(r.Body)
+	o.data = data
+	o.version++
+	o.etag = fmt.Sprintf(etagFormat, o.version)
+}
+
+// newTestProvider starts the fake server and creates a provider with the
+// factory. configure sets up the config for the server URL, with the
+// credentials encrypted with testMasterPassword as after Init.
+func newTestProvider(t *testing.T, server http.Handler, factory Factory, username, secret string,
+	configure func(cfg *config.Config, url string, username, secret []byte)) (Provider, *config.Config) {
+	t.Helper()
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	encryptedUsername, encryptedSecret, err := config.EncryptCredentials(username, secret, testMasterPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{}
+	configure(cfg, httpServer.URL, encryptedUsername, encryptedSecret)
+
+	provider, err := factory(context.Background(), cfg, testMasterPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, cfg
+}
+
+// writeTestStore writes a store file with the given content.
+func writeTestStore(t *testing.T, content string) string {
+	t.Helper()
+	storePath := filepath.Join(t.TempDir(), "store.dat")
+	if err := os.WriteFile(storePath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return storePath
+}
@@ -0,0 +1,412 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/utils"
+)
+
+// ProviderS3 is the name of the S3 provider in the config.
+const ProviderS3 = "s3"
+
+// Defaults for the S3 provider.
+const (
+	s3DefaultRegion = "us-east-1"
+	s3DefaultKey    = "vlxck/store.dat"
+	s3Timeout       = 60 * time.Second
+)
+
+func init() {
+	Register(ProviderS3, NewS3Sync)
+}
+
+// S3Sync implements synchronization with S3-compatible object storage such as
+// AWS S3, MinIO or Ceph. Pushes are checked against the ETag recorded by the
+// last sync and sent as conditional writes, so a remote copy changed from
+// another device is never overwritten. With bucket versioning enabled, every
+// pushed version is kept by the server.
+type S3Sync struct {
+	client         *http.Client
+	config         *config.Config
+	masterPassword string
+	ctx            context.Context
+
+	accessKey string // Decrypted on first use by credentials
+	secretKey string
+}
+
+// NewS3Sync creates a new S3 synchronizer instance
+func NewS3Sync(ctx context.Context, cfg *config.Config, masterPassword string) (Provider, error) {
+	return &S3Sync{
+		client:         &http.Client{Timeout: s3Timeout},
+		config:         cfg,
+		masterPassword: masterPassword,
+		ctx:            ctx,
+	}, nil
+}
+
+// notEmpty validates that a prompted value is not empty
+func notEmpty(what string) func(string) error {
+	return func(input string) error {
+		if strings.TrimSpace(input) == "" {
+			return fmt.Errorf("%s cannot be empty", what)
+		}
+		return nil
+	}
+}
+
+// Init prompts for the endpoint, bucket, object key and access keys,
+// verifies them against the server and stores them in the config. The
+// access keys are encrypted with the master password. An empty endpoint
+// selects AWS S3; other endpoints (MinIO, Ceph) use path-style URLs.
+func (s *S3Sync) Init() error {
+	settings := &s.config.Sync.S3
+
+	fmt.Println("Leave the endpoint empty for AWS S3, or enter the URL of an S3-compatible")
+	fmt.Println("server such as MinIO or Ceph, e.g. https://minio.example.com:9000")
+	endpoint, err := utils.PromptForInput("Endpoint", settings.Endpoint, func(input string) error {
+		if input == "" {
+			return nil
+		}
+		return validateHTTPURL(input)
+	})
+	if err != nil {
+		return err
+	}
+
+	region := settings.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	region, err = utils.PromptForInput("Region", region, notEmpty("region"))
+	if err != nil {
+		return err
+	}
+	bucket, err := utils.PromptForInput("Bucket", settings.Bucket, notEmpty("bucket"))
+	if err != nil {
+		return err
+	}
+	key := settings.Key
+	if key == "" {
+		key = s3DefaultKey
+	}
+	key, err = utils.PromptForInput("Object key", key, notEmpty("object key"))
+	if err != nil {
+		return err
+	}
+	accessKey, err := utils.PromptForInput("Access key ID", "", notEmpty("access key ID"))
+	if err != nil {
+		return err
+	}
+	secretKey := utils.PromptForPassword("Enter secret access key: ")
+
+	settings.Endpoint = strings.TrimSuffix(endpoint, "/")
+	settings.Region = region
+	settings.Bucket = bucket
+	settings.Key = strings.TrimPrefix(key, "/")
+	settings.PathStyle = settings.Endpoint != ""
+	s.accessKey, s.secretKey = accessKey, secretKey
+
+	// Check the credentials and the bucket
+	if err := s.checkBucket(); err != nil {
+		return err
+	}
+	if enabled, err := s.versioningEnabled(); err == nil && !enabled {
+		fmt.Println("Note: versioning is not enabled on the bucket. Enable it to keep every pushed version of the store.")
+	}
+
+	_, err = s.Metadata()
+	switch {
+	case errors.Is(err, ErrNoRemote):
+		fmt.Println("No remote store found. The first push will create it.")
+	case err != nil:
+		return err
+	default:
+		fmt.Println("Found an existing remote store. Pull it before pushing local changes.")
+	}
+
+	encryptedAccessKey, encryptedSecretKey, err := config.EncryptCredentials(accessKey, secretKey, s.masterPassword)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt access keys: %v", err)
+	}
+	settings.EncryptedAccessKey = encryptedAccessKey
+	settings.EncryptedSecretKey = encryptedSecretKey
+	// The ETag of a previous provider means nothing here
+	s.config.Sync.Etag = ""
+	return nil
+}
+
+// Push uploads the store file. The upload only succeeds if the remote object
+// still has the ETag recorded by the last sync, or does not exist if the
+// store was never synced.
+func (s *S3Sync) Push(storePath string) (*Metadata, error) {
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store: %v", err)
+	}
+
+	// Check first: not every S3-compatible server supports conditional writes
+	expected := s.config.Sync.Etag
+	current, err := s.Metadata()
+	switch {
+	case errors.Is(err, ErrNoRemote):
+		if expected != "" {
+			return nil, ErrRemoteChanged
+		}
+	case err != nil:
+		return nil, err
+	case current.ETag != expected:
+		return nil, ErrRemoteChanged
+	}
+
+	// Servers that support conditional writes (AWS S3, MinIO) also reject
+	// a push racing with another device
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	if expected != "" {
+		header.Set("If-Match", expected)
+	} else {
+		header.Set("If-None-Match", "*")
+	}
+
+	resp, err := s.do(http.MethodPut, s.objectURL(nil), data, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotImplemented {
+		// Conditional writes are not supported; rely on the check above
+		resp.Body.Close()
+		header.Del("If-Match")
+		header.Del("If-None-Match")
+		if resp, err = s.do(http.MethodPut, s.objectURL(nil), data, header); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict:
+		return nil, ErrRemoteChanged
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to upload object: %v", s3Error(resp))
+	}
+
+	meta := s3Metadata(resp)
+	if modified, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		meta.ModifiedAt = modified
+	}
+	return meta, nil
+}
+
+// Pull downloads the remote store object
+func (s *S3Sync) Pull(storePath string) (*Metadata, error) {
+	resp, err := s.do(http.MethodGet, s.objectURL(nil), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNoRemote
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to download object: %v", s3Error(resp))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(storePath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %v", err)
+	}
+	if err := utils.WriteFileAtomic(storePath, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write store: %v", err)
+	}
+
+	// The ETag of the response belongs to exactly the downloaded version
+	return s3Metadata(resp), nil
+}
+
+// Metadata returns the ETag, modification time and version ID of the remote object
+func (s *S3Sync) Metadata() (*Metadata, error) {
+	resp, err := s.do(http.MethodHead, s.objectURL(nil), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNoRemote
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to get object metadata: %s", resp.Status)
+	}
+	return s3Metadata(resp), nil
+}
+
+// checkBucket verifies that the bucket exists and the keys can access it
+func (s *S3Sync) checkBucket() error {
+	resp, err := s.do(http.MethodHead, s.bucketURL(nil), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("bucket %s does not exist", s.config.Sync.S3.Bucket)
+	case http.StatusMovedPermanently:
+		return fmt.Errorf("bucket %s is in another region (%s)", s.config.Sync.S3.Bucket, resp.Header.Get("X-Amz-Bucket-Region"))
+	default:
+		return fmt.Errorf("failed to access bucket %s: %s", s.config.Sync.S3.Bucket, resp.Status)
+	}
+}
+
+// versioningEnabled reports whether versioning is enabled on the bucket
+func (s *S3Sync) versioningEnabled() (bool, error) {
+	resp, err := s.do(http.MethodGet, s.bucketURL(url.Values{"versioning": {""}}), nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, s3Error(resp)
+	}
+
+	var versioning struct {
+		Status string `xml:"Status"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&versioning); err != nil {
+		return false, fmt.Errorf("failed to parse versioning configuration: %v", err)
+	}
+	return versioning.Status == "Enabled", nil
+}
+
+// bucketURL returns the URL of the bucket
+func (s *S3Sync) bucketURL(query url.Values) *url.URL {
+	return s.url("", query)
+}
+
+// objectURL returns the URL of the store object
+func (s *S3Sync) objectURL(query url.Values) *url.URL {
+	return s.url(s.config.Sync.S3.Key, query)
+}
+
+// url builds a path-style URL for custom endpoints and a virtual-hosted-style
+// URL for AWS. The path is encoded exactly as it is signed.
+func (s *S3Sync) url(key string, query url.Values) *url.URL {
+	settings := s.config.Sync.S3
+	u := &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", settings.Bucket, settings.Region)}
+	segments := []string{""}
+	if settings.Endpoint != "" {
+		if endpoint, err := url.Parse(settings.Endpoint); err == nil {
+			u.Scheme, u.Host = endpoint.Scheme, endpoint.Host
+		}
+	}
+	if settings.PathStyle {
+		segments = append(segments, settings.Bucket)
+	}
+	if key != "" {
+		segments = append(segments, strings.Split(key, "/")...)
+	} else if !settings.PathStyle {
+		segments = append(segments, "")
+	}
+
+	encoded := make([]string, len(segments))
+	for i, segment := range segments {
+		encoded[i] = uriEncode(segment)
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = strings.Join(encoded, "/")
+	u.RawQuery = query.Encode()
+	return u
+}
+
+// do sends a signed request
+func (s *S3Sync) do(method string, target *url.URL, payload []byte, header http.Header) (*http.Response, error) {
+	if s.config.Sync.S3.Bucket == "" {
+		return nil, fmt.Errorf("S3 sync is not initialized; run 'vlxck sync --init --provider s3' first")
+	}
+	accessKey, secretKey, err := s.credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, method, target.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	if payload == nil {
+		req.Body, req.ContentLength = http.NoBody, 0
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	signV4(req, payload, accessKey, secretKey, s.config.Sync.S3.Region, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach S3 server: %v", err)
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("S3 server denied access: %v", s3Error(resp))
+	}
+	return resp, nil
+}
+
+// credentials returns the decrypted access keys
+func (s *S3Sync) credentials() (string, string, error) {
+	if s.accessKey == "" {
+		accessKey, secretKey, err := config.DecryptCredentials(s.config.Sync.S3.EncryptedAccessKey, s.config.Sync.S3.EncryptedSecretKey, s.masterPassword)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decrypt S3 access keys: %v", err)
+		}
+		s.accessKey, s.secretKey = accessKey, secretKey
+	}
+	return s.accessKey, s.secretKey, nil
+}
+
+// s3Metadata extracts the metadata of the remote object from a response
+func s3Metadata(resp *http.Response) *Metadata {
+	meta := &Metadata{
+		ETag:    resp.Header.Get("ETag"),
+		Version: resp.Header.Get("X-Amz-Version-Id"),
+	}
+	if meta.Version == "null" {
+		// Objects written while versioning was off
+		meta.Version = ""
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		meta.ModifiedAt = modified
+	}
+	return meta
+}
+
+// s3Error describes a failed response, using the error code and message from
+// the XML body when the server sent one
+func s3Error(resp *http.Response) error {
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body); err != nil || body.Code == "" {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return fmt.Errorf("%s: %s (%s)", body.Code, body.Message, resp.Status)
+}
@@ -0,0 +1,255 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+)
+
+const (
+	testS3Bucket    = "vault"
+	testS3Key       = "vlxck/store.dat"
+	testS3AccessKey = "minioadmin"
+	testS3SecretKey = "minio-secret"
+)
+
+// fakeS3 is an in-process S3-compatible server holding a single object.
+type fakeS3 struct {
+	fakeObject
+
+	conditional  bool // Honour If-Match and If-None-Match on PUT
+	racingETag   string
+	unsupported  bool // Answer conditional PUTs with 501 Not Implemented
+	puts         []http.Header
+	unauthorized int // Requests without a SigV4 Authorization header
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+testS3AccessKey+"/") {
+		f.unauthorized++
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if r.URL.EscapedPath() != "/"+testS3Bucket+"/"+testS3Key {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodHead, http.MethodGet:
+		if f.data != nil {
+			w.Header().Set("X-Amz-Version-Id", fmt.Sprintf("version-%d", f.version))
+		}
+		f.serveRead(w, r)
+	case http.MethodPut:
+		f.puts = append(f.puts, r.Header.Clone())
+		conditional := r.Header.Get("If-Match") != "" || r.Header.Get("If-None-Match") != ""
+		if conditional && f.unsupported {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		if f.racingETag != "" {
+			// Another device pushed between the check and the upload
+			f.etag, f.racingETag = f.racingETag, ""
+		}
+		if f.conditional && f.preconditionFailed(r) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.replace(r, `"etag-%d"`)
+		w.Header().Set("ETag", f.etag)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newTestS3 starts the fake server and returns a provider configured for it
+// as a path-style custom endpoint.
+func newTestS3(t *testing.T, fake *fakeS3) (*S3Sync, *config.Config) {
+	t.Helper()
+	provider, cfg := newTestProvider(t, fake, NewS3Sync, testS3AccessKey, testS3SecretKey,
+		func(cfg *config.Config, url string, accessKey, secretKey []byte) {
+			cfg.Sync.S3.Endpoint = url
+			cfg.Sync.S3.Region = s3DefaultRegion
+			cfg.Sync.S3.Bucket = testS3Bucket
+			cfg.Sync.S3.Key = testS3Key
+			cfg.Sync.S3.PathStyle = true
+			cfg.Sync.S3.EncryptedAccessKey = accessKey
+			cfg.Sync.S3.EncryptedSecretKey = secretKey
+		})
+	return provider.(*S3Sync), cfg
+}
+
+func TestS3FirstPush(t *testing.T) {
+	fake := &fakeS3{conditional: true}
+	s, _ := newTestS3(t, fake)
+	storePath := writeTestStore(t, "local")
+
+	meta, err := s.Push(storePath)
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if len(fake.puts) != 1 || fake.puts[0].Get("If-None-Match") != "*" {
+		t.Errorf("PUT headers = %v, want one PUT with If-None-Match: *", fake.puts)
+	}
+	if meta.ETag != fake.etag || string(fake.data) != "local" {
+		t.Errorf("after push: ETag = %q, remote = %q", meta.ETag, fake.data)
+	}
+	if fake.unauthorized != 0 {
+		t.Errorf("%d requests were not signed", fake.unauthorized)
+	}
+}
+
+func TestS3NoRemote(t *testing.T) {
+	fake := &fakeS3{conditional: true}
+	s, cfg := newTestS3(t, fake)
+
+	if _, err := s.Metadata(); !errors.Is(err, ErrNoRemote) {
+		t.Errorf("Metadata: err = %v, want ErrNoRemote", err)
+	}
+	if _, err := s.Pull(filepath.Join(t.TempDir(), "store.dat")); !errors.Is(err, ErrNoRemote) {
+		t.Errorf("Pull: err = %v, want ErrNoRemote", err)
+	}
+
+	// A remote that disappeared after a sync is not overwritten blindly
+	cfg.Sync.Etag = `"etag-1"`
+	if _, err := s.Push(writeTestStore(t, "local")); !errors.Is(err, ErrRemoteChanged) {
+		t.Errorf("Push with recorded ETag: err = %v, want ErrRemoteChanged", err)
+	}
+	if len(fake.puts) != 0 {
+		t.Errorf("%d PUT requests sent, want none", len(fake.puts))
+	}
+}
+
+func TestS3ConditionalPushRejected(t *testing.T) {
+	fake := &fakeS3{fakeObject: fakeObject{data: []byte("remote"), etag: `"etag-1"`, version: 1}, conditional: true, racingETag: `"etag-other"`}
+	s, cfg := newTestS3(t, fake)
+	cfg.Sync.Etag = `"etag-1"`
+
+	if _, err := s.Push(writeTestStore(t, "local")); !errors.Is(err, ErrRemoteChanged) {
+		t.Fatalf("Push: err = %v, want ErrRemoteChanged", err)
+	}
+	if got := fake.puts[0].Get("If-Match"); got != `"etag-1"` {
+		t.Errorf("If-Match = %q, want %q", got, `"etag-1"`)
+	}
+	if string(fake.data) != "remote" {
+		t.Errorf("remote was overwritten with %q", fake.data)
+	}
+}
+
+func TestS3StaleETag(t *testing.T) {
+	fake := &fakeS3{fakeObject: fakeObject{data: []byte("remote"), etag: `"etag-2"`, version: 2}, conditional: true}
+	s, cfg := newTestS3(t, fake)
+	cfg.Sync.Etag = `"etag-1"`
+
+	if _, err := s.Push(writeTestStore(t, "local")); !errors.Is(err, ErrRemoteChanged) {
+		t.Fatalf("Push: err = %v, want ErrRemoteChanged", err)
+	}
+	if len(fake.puts) != 0 {
+		t.Errorf("%d PUT requests sent, want none", len(fake.puts))
+	}
+}
+
+func TestS3ConditionalWritesUnsupported(t *testing.T) {
+	fake := &fakeS3{fakeObject: fakeObject{data: []byte("remote"), etag: `"etag-1"`, version: 1}, unsupported: true}
+	s, cfg := newTestS3(t, fake)
+	cfg.Sync.Etag = `"etag-1"`
+
+	meta, err := s.Push(writeTestStore(t, "local"))
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if len(fake.puts) != 2 {
+		t.Fatalf("PUT requests = %d, want 2", len(fake.puts))
+	}
+	if fake.puts[0].Get("If-Match") != `"etag-1"` {
+		t.Errorf("first PUT If-Match = %q, want %q", fake.puts[0].Get("If-Match"), `"etag-1"`)
+	}
+	if fake.puts[1].Get("If-Match") != "" || fake.puts[1].Get("If-None-Match") != "" {
+		t.Errorf("fallback PUT is conditional: %v", fake.puts[1])
+	}
+	if meta.ETag != fake.etag || string(fake.data) != "local" {
+		t.Errorf("after push: ETag = %q, remote = %q", meta.ETag, fake.data)
+	}
+}
+
+func TestS3Pull(t *testing.T) {
+	fake := &fakeS3{fakeObject: fakeObject{data: []byte("remote"), etag: `"etag-3"`, version: 3}}
+	s, _ := newTestS3(t, fake)
+	storePath := filepath.Join(t.TempDir(), "store.dat")
+
+	meta, err := s.Pull(storePath)
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if meta.ETag != `"etag-3"` || meta.Version != "version-3" {
+		t.Errorf("metadata = %+v", meta)
+	}
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "remote" {
+		t.Errorf("store = %q, want %q", data, "remote")
+	}
+}
+
+func TestS3URL(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoint  string
+		pathStyle bool
+		key       string
+		object    string
+		bucket    string
+	}{
+		{
+			name:   "AWS virtual-hosted style",
+			key:    "vlxck/store.dat",
+			object: "https://vault.s3.eu-west-1.amazonaws.com/vlxck/store.dat",
+			bucket: "https://vault.s3.eu-west-1.amazonaws.com/",
+		},
+		{
+			name:      "custom endpoint path style",
+			endpoint:  "http://127.0.0.1:9000",
+			pathStyle: true,
+			key:       "vlxck/store.dat",
+			object:    "http://127.0.0.1:9000/vault/vlxck/store.dat",
+			bucket:    "http://127.0.0.1:9000/vault",
+		},
+		{
+			name:      "key with special characters",
+			endpoint:  "https://minio.example.com",
+			pathStyle: true,
+			key:       "my vault/store$1.dat",
+			object:    "https://minio.example.com/vault/my%20vault/store%241.dat",
+			bucket:    "https://minio.example.com/vault",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Sync.S3.Endpoint = tt.endpoint
+			cfg.Sync.S3.PathStyle = tt.pathStyle
+			cfg.Sync.S3.Region = "eu-west-1"
+			cfg.Sync.S3.Bucket = testS3Bucket
+			cfg.Sync.S3.Key = tt.key
+			s := &S3Sync{config: cfg}
+
+			if got := s.objectURL(nil).String(); got != tt.object {
+				t.Errorf("objectURL = %s, want %s", got, tt.object)
+			}
+			if got := s.bucketURL(nil).String(); got != tt.bucket {
+				t.Errorf("bucketURL = %s, want %s", got, tt.bucket)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// State describes how the local store relates to the remote copy.
+type State string
+
+// Sync states.
+const (
+	StateUpToDate  State = "up to date" // Neither side changed since the last sync
+	StateAhead     State = "ahead"      // Only the local store changed
+	StateBehind    State = "behind"     // Only the remote copy changed
+	StateDiverged  State = "diverged"   // Both sides changed
+	StateNotSynced State = "not synced" // The store was never pushed or pulled
+)
+
+// Status reports the state of the local store compared to the remote copy.
+type Status struct {
+	// Provider is the name of the configured provider
+	Provider string
+	// State is how the local store relates to the remote copy
+	State State
+	// LastSync is when the store was last pushed or pulled (zero if never)
+	LastSync time.Time
+	// Remote is the metadata of the remote copy (nil if it does not exist)
+	Remote *Metadata
+}
+
+// Status compares the local store and the remote copy with the state of the
+// last sync: the local store with the snapshot taken by the last push or
+// pull, and the remote ETag with the recorded one.
+//
+// Returns:
+//   - *Status: The sync status
+//   - error: An error if the remote copy cannot be checked
+func (s *SyncManager) Status() (*Status, error) {
+	status := &Status{Provider: s.config.Sync.Provider, State: StateNotSynced}
+	if status.Provider == "" {
+		status.Provider = DefaultProvider
+	}
+
+	remote, err := s.provider.Metadata()
+	if err != nil && !errors.Is(err, ErrNoRemote) {
+		return nil, err
+	}
+	status.Remote = remote
+
+	if info, err := os.Stat(basePath(s.storePath)); err == nil {
+		status.LastSync = info.ModTime()
+	}
+
+	local, synced, err := localChanged(s.storePath)
+	if err != nil {
+		return nil, err
+	}
+	if !synced {
+		return status, nil
+	}
+
+	// A remote copy deleted since the last sync has to be pushed again
+	if remote == nil {
+		local = true
+	}
+	remoteChanged := remote != nil && remote.ETag != s.config.Sync.Etag
+	switch {
+	case local && remoteChanged:
+		status.State = StateDiverged
+	case local:
+		status.State = StateAhead
+	case remoteChanged:
+		status.State = StateBehind
+	default:
+		status.State = StateUpToDate
+	}
+	return status, nil
+}
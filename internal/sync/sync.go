@@ -1,21 +1,237 @@
 // Package sync implements the synchronization functionality
-// for the secrets store with Google Drive.
+// for the secrets store with remote storage providers.
+//
+// Providers register themselves under the name used for sync.provider in the
+// config (see Register), so that new backends plug in without changes to
+// the sync command.
 package sync
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/store"
+	"github.com/kirinyoku/vlxck/internal/utils"
 )
 
+// DefaultProvider is used when no provider is configured.
+const DefaultProvider = ProviderGoogleDrive
+
+// ErrRemoteChanged is returned by Push when the remote copy was modified
+// since it was last pulled or pushed, so that a newer remote is never
+// overwritten. Pull first to get the latest version.
+var ErrRemoteChanged = errors.New("remote store was changed since the last sync; pull first")
+
+// ErrNoRemote is returned when the remote copy of the store does not exist yet.
+var ErrNoRemote = errors.New("remote store does not exist yet; push first")
+
+// ErrConflicts is returned by a sync that would have to ask which version of
+// a conflicting secret to keep, but cannot ask because standard input is not
+// a terminal or prompting was turned off (see NoPrompt). The local store is
+// left unchanged.
+var ErrConflicts = errors.New("secrets were changed both locally and on the remote; run 'vlxck sync -m pull' in a terminal to resolve the conflicts")
+
+// Mode is the direction of a synchronization.
+type Mode string
+
+// Sync modes.
+const (
+	ModePush Mode = "push" // Upload the local store
+	ModePull Mode = "pull" // Download the remote store
+)
+
+// Metadata describes the remote copy of the store.
+type Metadata struct {
+	// ETag identifies the version of the remote copy (e.g. an ETag or checksum)
+	ETag string
+	// ModifiedAt is when the remote copy was last modified
+	ModifiedAt time.Time
+	// Version identifies the remote copy among the versions kept by the
+	// provider, if it keeps any (e.g. an S3 version ID)
+	Version string
+}
+
+// Provider is a remote storage backend for the store file.
+type Provider interface {
+	// Init configures the provider interactively, storing its settings in
+	// the config passed to the provider's factory
+	Init() error
+	// Push uploads the store file and returns the metadata of the new remote copy
+	Push(storePath string) (*Metadata, error)
+	// Pull downloads the remote copy to the store file and returns its metadata
+	Pull(storePath string) (*Metadata, error)
+	// Metadata returns the metadata of the remote copy
+	Metadata() (*Metadata, error)
+}
+
+// Recorder is implemented by providers that keep a local history of the
+// store, so that every saved change can be recorded as it happens.
+type Recorder interface {
+	// Record adds the current state of the store file to the history
+	Record(storePath, message string) error
+}
+
+// Prompter is implemented by providers that may ask the user for input
+// outside of Init, e.g. for credentials of a remote, and can be told not to.
+type Prompter interface {
+	// NoPrompt makes the provider fail instead of asking the user
+	NoPrompt()
+}
+
+// Factory creates a provider. Credentials kept in the config are encrypted
+// with the master password.
+//
+// Parameters:
+//   - ctx: The context
+//   - cfg: The configuration the provider reads and updates its settings in
+//   - masterPassword: The master password
+//
+// Returns:
+//   - Provider: The provider
+//   - error: An error if the provider cannot be created
+type Factory func(ctx context.Context, cfg *config.Config, masterPassword string) (Provider, error)
+
+// providers holds the registered provider factories by name.
+var providers = map[string]Factory{}
+
+// UsesCredentials reports whether the provider keeps credentials in the
+// config, encrypted with the master password. The git provider relies on the
+// user's git setup instead and never needs the master password to sync.
+func UsesCredentials(name string) bool {
+	return name != ProviderGit
+}
+
+// Register makes a provider available under the name used for sync.provider
+// in the config. It is meant to be called from the init function of the file
+// implementing the provider.
+func Register(name string, factory Factory) {
+	if _, ok := providers[name]; ok {
+		panic(fmt.Sprintf("sync: provider %s registered twice", name))
+	}
+	providers[name] = factory
+}
+
+// Providers returns the names of the registered providers in sorted order.
+func Providers() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider creates the registered provider with the given name.
+//
+// Parameters:
+//   - ctx: The context
+//   - name: The provider name (empty selects DefaultProvider)
+//   - cfg: The configuration
+//   - masterPassword: The master password
+//
+// Returns:
+//   - Provider: The provider
+//   - error: An error if the provider is unknown or cannot be created
+func NewProvider(ctx context.Context, name string, cfg *config.Config, masterPassword string) (Provider, error) {
+	if name == "" {
+		name = DefaultProvider
+	}
+	factory, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sync provider '%s' (available: %v)", name, Providers())
+	}
+	return factory(ctx, cfg, masterPassword)
+}
+
+// Init configures the provider with the given name and makes it the
+// configured sync provider. The merge base of the previous remote is
+// forgotten.
+//
+// Parameters:
+//   - ctx: The context
+//   - storePath: The path to the secrets store
+//   - name: The provider name (empty keeps the configured provider)
+//   - masterPassword: The master password
+//
+// Returns:
+//   - string: The name of the initialized provider
+//   - error: An error if initialization fails
+func Init(ctx context.Context, storePath, name, masterPassword string) (string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %v", err)
+	}
+	if name == "" {
+		name = cfg.Sync.Provider
+	}
+	if name == "" {
+		name = DefaultProvider
+	}
+
+	provider, err := NewProvider(ctx, name, cfg, masterPassword)
+	if err != nil {
+		return "", err
+	}
+	if err := provider.Init(); err != nil {
+		return "", err
+	}
+
+	cfg.Sync.Provider = name
+	if err := config.SaveConfig(cfg); err != nil {
+		return "", fmt.Errorf("failed to save config: %v", err)
+	}
+	if err := removeBase(storePath); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Record records a saved change of the store with the configured provider
+// if it keeps a local history. It does nothing for other providers or when
+// sync is not set up.
+//
+// Parameters:
+//   - storePath: The path to the secrets store
+//   - message: A description of the change; it must not reveal secrets
+//
+// Returns:
+//   - An error if the change cannot be recorded
+func Record(storePath, message string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	if cfg.Sync.Provider == "" {
+		return nil
+	}
+
+	// Recording never needs credentials, so no master password is passed
+	provider, err := NewProvider(context.Background(), cfg.Sync.Provider, cfg, "")
+	if err != nil {
+		return err
+	}
+	if recorder, ok := provider.(Recorder); ok {
+		return recorder.Record(storePath, message)
+	}
+	return nil
+}
+
 // SyncManager represents the sync manager
 type SyncManager struct {
 	storePath      string
 	masterPassword string
-	gdrive         *GoogleDriveSync
-	ctx            context.Context
+	key            *store.Key // Optional store key for merging, see UseKey
+	prompt         bool       // Whether conflicts may be resolved by asking the user
+	config         *config.Config
+	provider       Provider
 }
 
-// NewSyncManager creates a new sync manager
+// NewSyncManager creates a new sync manager for the configured provider
 //
 // Parameters:
 //   - ctx: The context
@@ -26,33 +242,118 @@ type SyncManager struct {
 //   - A new sync manager
 //   - An error if the sync manager cannot be created
 func NewSyncManager(ctx context.Context, storePath, masterPassword string) (*SyncManager, error) {
-	gdrive, err := NewGoogleDriveSync(ctx, masterPassword)
+	cfg, err := config.LoadConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Google Drive sync: %v", err)
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	provider, err := NewProvider(ctx, cfg.Sync.Provider, cfg, masterPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sync provider: %v", err)
 	}
 
 	return &SyncManager{
 		storePath:      storePath,
 		masterPassword: masterPassword,
-		gdrive:         gdrive,
-		ctx:            ctx,
+		prompt:         utils.StdinIsTerminal(),
+		config:         cfg,
+		provider:       provider,
 	}, nil
 }
 
-// Sync synchronizes the secrets store with Google Drive
+// UseKey lets the manager decrypt the local store with an already derived
+// key when merging, so that the master password is only needed if the
+// provider keeps credentials.
+func (s *SyncManager) UseKey(key *store.Key) {
+	s.key = key
+}
+
+// NoPrompt makes a merge with conflicting changes fail with ErrConflicts
+// instead of asking the user, e.g. for syncs run automatically around other
+// commands. Providers that implement Prompter fail instead of prompting too.
+func (s *SyncManager) NoPrompt() {
+	s.prompt = false
+	if prompter, ok := s.provider.(Prompter); ok {
+		prompter.NoPrompt()
+	}
+}
+
+// LocalChanges reports whether the local store has changes that were not
+// pushed yet: it changed since the last sync, or was never synced at all.
+func (s *SyncManager) LocalChanges() (bool, error) {
+	if _, err := os.Stat(s.storePath); os.IsNotExist(err) {
+		return false, nil
+	}
+	changed, synced, err := localChanged(s.storePath)
+	return changed || !synced, err
+}
+
+// Sync synchronizes the secrets store with the provider and records the
+// ETag of the remote copy in the config. A pull merges the remote store into
+// the local one secret by secret instead of replacing it (see store.Merge),
+// using the store as of the last sync as the common base. A push first
+// compares the remote ETag with the recorded one and, if another device
+// pushed in the meantime, merges the remote changes before uploading.
 //
 // Parameters:
-//   - mode: The sync mode, either "push" or "pull"
+//   - mode: The sync mode, either ModePush or ModePull
 //
 // Returns:
 //   - An error if the sync fails
-func (s *SyncManager) Sync(mode string) error {
+func (s *SyncManager) Sync(mode Mode) error {
+	var meta *Metadata
+	var err error
 	switch mode {
-	case "push":
-		return s.gdrive.Push(s.storePath)
-	case "pull":
-		return s.gdrive.Pull(s.storePath)
+	case ModePush:
+		meta, err = s.push()
+	case ModePull:
+		meta, err = s.pull()
 	default:
 		return fmt.Errorf("invalid sync mode: %s", mode)
 	}
+	if err != nil {
+		return err
+	}
+
+	return s.saveEtag(meta)
+}
+
+// push uploads the store, merging remote changes first if the remote copy
+// no longer has the ETag recorded by the last sync.
+func (s *SyncManager) push() (*Metadata, error) {
+	remote, err := s.provider.Metadata()
+	switch {
+	case errors.Is(err, ErrNoRemote):
+		// First push, or the remote copy was deleted: the recorded ETag
+		// belongs to a copy that no longer exists, and conditional pushes
+		// expecting it would fail forever
+		s.config.Sync.Etag = ""
+	case err != nil:
+		return nil, err
+	case remote.ETag != s.config.Sync.Etag:
+		fmt.Fprintln(os.Stderr, "The remote store was changed since the last sync; merging the remote changes first.")
+		meta, err := s.pull()
+		if err != nil {
+			return nil, err
+		}
+		// Conditional pushes must expect the version just merged
+		if err := s.saveEtag(meta); err != nil {
+			return nil, err
+		}
+	}
+
+	meta, err := s.provider.Push(s.storePath)
+	if err != nil {
+		return nil, err
+	}
+	return meta, saveBase(s.storePath)
+}
+
+// saveEtag records the ETag of the remote copy in the config
+func (s *SyncManager) saveEtag(meta *Metadata) error {
+	s.config.Sync.Etag = meta.ETag
+	if err := config.SaveConfig(s.config); err != nil {
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	return nil
 }
@@ -0,0 +1,294 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+	"github.com/kirinyoku/vlxck/internal/utils"
+)
+
+// ProviderWebDAV is the name of the WebDAV provider in the config.
+const ProviderWebDAV = "webdav"
+
+// webdavTimeout bounds each request to the WebDAV server.
+const webdavTimeout = 60 * time.Second
+
+func init() {
+	Register(ProviderWebDAV, NewWebDAVSync)
+}
+
+// WebDAVSync implements synchronization with a WebDAV server such as Nextcloud.
+// Pushes are conditional on the ETag recorded by the last sync, so a remote
+// copy changed from another device is never overwritten.
+type WebDAVSync struct {
+	client         *http.Client
+	config         *config.Config
+	masterPassword string
+	ctx            context.Context
+
+	username string // Decrypted on first use by credentials
+	password string
+}
+
+// NewWebDAVSync creates a new WebDAV synchronizer instance
+func NewWebDAVSync(ctx context.Context, cfg *config.Config, masterPassword string) (Provider, error) {
+	return &WebDAVSync{
+		client:         &http.Client{Timeout: webdavTimeout},
+		config:         cfg,
+		masterPassword: masterPassword,
+		ctx:            ctx,
+	}, nil
+}
+
+// validateHTTPURL checks that the URL points to an HTTP(S) server
+func validateHTTPURL(input string) error {
+	u, err := url.Parse(input)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL must start with https:// or http://")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL must include a host")
+	}
+	return nil
+}
+
+// Init prompts for the URL of the remote store file and the credentials,
+// verifies them against the server and stores them in the config. The
+// credentials are encrypted with the master password. A URL ending in a
+// slash is taken as a folder and store.dat is appended.
+func (w *WebDAVSync) Init() error {
+	fmt.Println("Enter the WebDAV URL of the remote store, e.g. for Nextcloud:")
+	fmt.Println("  https://cloud.example.com/remote.php/dav/files/USERNAME/vlxck/store.dat")
+
+	rawURL, err := utils.PromptForInput("WebDAV URL", w.config.Sync.WebDAV.URL, validateHTTPURL)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(rawURL, "/") {
+		rawURL += "store.dat"
+	}
+
+	username, err := utils.PromptForInput("Username", "", func(input string) error {
+		if input == "" {
+			return fmt.Errorf("username cannot be empty")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Nextcloud users with two-factor authentication need an app password
+	password := utils.PromptForPassword("Enter WebDAV password (or app password): ")
+
+	w.config.Sync.WebDAV.URL = rawURL
+	w.username, w.password = username, password
+
+	// Check the credentials and make sure the folder for the store exists
+	_, err = w.Metadata()
+	switch {
+	case errors.Is(err, ErrNoRemote):
+		if err := w.ensureCollection(); err != nil {
+			return err
+		}
+		fmt.Println("No remote store found. The first push will create it.")
+	case err != nil:
+		return err
+	default:
+		fmt.Println("Found an existing remote store. Pull it before pushing local changes.")
+	}
+
+	encryptedUsername, encryptedPassword, err := config.EncryptCredentials(username, password, w.masterPassword)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt credentials: %v", err)
+	}
+	w.config.Sync.WebDAV.EncryptedUsername = encryptedUsername
+	w.config.Sync.WebDAV.EncryptedPassword = encryptedPassword
+	// The ETag of a previous provider means nothing here
+	w.config.Sync.Etag = ""
+	return nil
+}
+
+// Push uploads the store file. The upload only succeeds if the remote copy
+// still has the ETag recorded by the last sync, or does not exist if the
+// store was never synced.
+func (w *WebDAVSync) Push(storePath string) (*Metadata, error) {
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store: %v", err)
+	}
+
+	header := http.Header{}
+	if w.config.Sync.Etag != "" {
+		header.Set("If-Match", w.config.Sync.Etag)
+	} else {
+		header.Set("If-None-Match", "*")
+	}
+
+	resp, err := w.do(http.MethodPut, bytes.NewReader(data), header)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return nil, ErrRemoteChanged
+	case resp.StatusCode == http.StatusConflict:
+		return nil, fmt.Errorf("failed to upload file: the remote folder does not exist")
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("failed to upload file: %s", resp.Status)
+	}
+
+	// Servers may omit the ETag of the new version; ask for it then
+	if meta := webdavMetadata(resp); meta.ETag != "" {
+		return meta, nil
+	}
+	return w.Metadata()
+}
+
+// Pull downloads the remote store file
+func (w *WebDAVSync) Pull(storePath string) (*Metadata, error) {
+	resp, err := w.do(http.MethodGet, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNoRemote
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to download file: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(storePath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %v", err)
+	}
+	if err := utils.WriteFileAtomic(storePath, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write store: %v", err)
+	}
+
+	// The ETag of the response belongs to exactly the downloaded version
+	return webdavMetadata(resp), nil
+}
+
+// Metadata returns the ETag and modification time of the remote store file
+func (w *WebDAVSync) Metadata() (*Metadata, error) {
+	resp, err := w.do(http.MethodHead, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNoRemote
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to get file metadata: %s", resp.Status)
+	}
+	return webdavMetadata(resp), nil
+}
+
+// ensureCollection creates the folder that holds the remote store file.
+// Only the last folder is created; its parent must exist.
+func (w *WebDAVSync) ensureCollection() error {
+	u, err := url.Parse(w.config.Sync.WebDAV.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+	u.Path = path.Dir(u.Path) + "/"
+
+	req, err := w.newRequest("MKCOL", u.String(), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to create remote folder: %v", err)
+	}
+	resp.Body.Close()
+
+	// 405 Method Not Allowed means the folder already exists
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("failed to create remote folder %s: %s", u.Path, resp.Status)
+	}
+	return nil
+}
+
+// do sends a request for the remote store file
+func (w *WebDAVSync) do(method string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := w.newRequest(method, w.config.Sync.WebDAV.URL, body, header)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach WebDAV server: %v", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		return nil, fmt.Errorf("WebDAV server rejected the credentials: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// newRequest creates an authenticated request
+func (w *WebDAVSync) newRequest(method, target string, body io.Reader, header http.Header) (*http.Request, error) {
+	if target == "" {
+		return nil, fmt.Errorf("WebDAV sync is not initialized; run 'vlxck sync --init --provider webdav' first")
+	}
+	username, password, err := w.credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(w.ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.SetBasicAuth(username, password)
+	return req, nil
+}
+
+// credentials returns the decrypted username and password
+func (w *WebDAVSync) credentials() (string, string, error) {
+	if w.username == "" {
+		username, password, err := config.DecryptCredentials(w.config.Sync.WebDAV.EncryptedUsername, w.config.Sync.WebDAV.EncryptedPassword, w.masterPassword)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decrypt WebDAV credentials: %v", err)
+		}
+		w.username, w.password = username, password
+	}
+	return w.username, w.password, nil
+}
+
+// webdavMetadata extracts the metadata of the remote file from a response
+func webdavMetadata(resp *http.Response) *Metadata {
+	meta := &Metadata{ETag: resp.Header.Get("ETag")}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		meta.ModifiedAt = modified
+	}
+	return meta
+}
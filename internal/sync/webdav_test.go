@@ -0,0 +1,204 @@
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kirinyoku/vlxck/internal/config"
+)
+
+const (
+	testDAVUser     = "alice"
+	testDAVPassword = "app-password"
+)
+
+// fakeDAV is an in-process WebDAV server holding a single file. It honours
+// If-Match and If-None-Match on PUT like Nextcloud does.
+type fakeDAV struct {
+	fakeObject
+	omitETag bool // Leave the ETag out of PUT responses
+
+	heads int         // Number of HEAD requests
+	put   http.Header // Headers of the last PUT request
+}
+
+func (f *fakeDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, password, ok := r.BasicAuth(); !ok || user != testDAVUser || password != testDAVPassword {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodHead, http.MethodGet:
+		if r.Method == http.MethodHead {
+			f.heads++
+		}
+		f.serveRead(w, r)
+	case http.MethodPut:
+		f.put = r.Header.Clone()
+		if f.preconditionFailed(r) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.replace(r, `"v%d"`)
+		if !f.omitETag {
+			w.Header().Set("ETag", f.etag)
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newTestWebDAV starts the fake server and returns a provider configured for it.
+func newTestWebDAV(t *testing.T, dav *fakeDAV) (*WebDAVSync, *config.Config) {
+	t.Helper()
+	provider, cfg := newTestProvider(t, dav, NewWebDAVSync, testDAVUser, testDAVPassword,
+		func(cfg *config.Config, url string, username, password []byte) {
+			cfg.Sync.WebDAV.URL = url + "/vlxck/store.dat"
+			cfg.Sync.WebDAV.EncryptedUsername = username
+			cfg.Sync.WebDAV.EncryptedPassword = password
+		})
+	return provider.(*WebDAVSync), cfg
+}
+
+func TestWebDAVFirstPush(t *testing.T) {
+	dav := &fakeDAV{}
+	w, cfg := newTestWebDAV(t, dav)
+	storePath := writeTestStore(t, "local")
+
+	meta, err := w.Push(storePath)
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if got := dav.put.Get("If-None-Match"); got != "*" {
+		t.Errorf("If-None-Match = %q, want *", got)
+	}
+	if got := dav.put.Get("If-Match"); got != "" {
+		t.Errorf("If-Match = %q, want none", got)
+	}
+	if meta.ETag != dav.etag {
+		t.Errorf("ETag = %q, want %q", meta.ETag, dav.etag)
+	}
+	if string(dav.data) != "local" {
+		t.Errorf("remote = %q, want %q", dav.data, "local")
+	}
+
+	// Another device pushing first must not overwrite the existing copy
+	cfg.Sync.Etag = ""
+	if _, err := w.Push(storePath); !errors.Is(err, ErrRemoteChanged) {
+		t.Errorf("second first push: err = %v, want ErrRemoteChanged", err)
+	}
+}
+
+func TestWebDAVPushStaleETag(t *testing.T) {
+	dav := &fakeDAV{fakeObject: fakeObject{data: []byte("remote"), etag: `"v1"`, version: 1}}
+	w, cfg := newTestWebDAV(t, dav)
+	storePath := writeTestStore(t, "local")
+
+	cfg.Sync.Etag = `"v0"`
+	if _, err := w.Push(storePath); !errors.Is(err, ErrRemoteChanged) {
+		t.Fatalf("Push: err = %v, want ErrRemoteChanged", err)
+	}
+	if got := dav.put.Get("If-Match"); got != `"v0"` {
+		t.Errorf("If-Match = %q, want %q", got, `"v0"`)
+	}
+	if string(dav.data) != "remote" {
+		t.Errorf("remote was overwritten with %q", dav.data)
+	}
+
+	cfg.Sync.Etag = `"v1"`
+	meta, err := w.Push(storePath)
+	if err != nil {
+		t.Fatalf("Push with current ETag: %v", err)
+	}
+	if meta.ETag != `"v2"` || string(dav.data) != "local" {
+		t.Errorf("after push: ETag = %q, remote = %q", meta.ETag, dav.data)
+	}
+}
+
+func TestWebDAVPushWithoutETag(t *testing.T) {
+	dav := &fakeDAV{omitETag: true}
+	w, _ := newTestWebDAV(t, dav)
+	storePath := writeTestStore(t, "local")
+
+	meta, err := w.Push(storePath)
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if dav.heads != 1 {
+		t.Errorf("HEAD requests = %d, want 1", dav.heads)
+	}
+	if meta.ETag != dav.etag {
+		t.Errorf("ETag = %q, want %q from Metadata", meta.ETag, dav.etag)
+	}
+}
+
+func TestWebDAVPull(t *testing.T) {
+	dav := &fakeDAV{}
+	w, _ := newTestWebDAV(t, dav)
+	storePath := filepath.Join(t.TempDir(), "nested", "store.dat")
+
+	if _, err := w.Pull(storePath); !errors.Is(err, ErrNoRemote) {
+		t.Fatalf("Pull without remote: err = %v, want ErrNoRemote", err)
+	}
+	if _, err := w.Metadata(); !errors.Is(err, ErrNoRemote) {
+		t.Errorf("Metadata without remote: err = %v, want ErrNoRemote", err)
+	}
+
+	dav.data, dav.etag = []byte("remote"), `"v7"`
+	meta, err := w.Pull(storePath)
+	if err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if meta.ETag != `"v7"` {
+		t.Errorf("ETag = %q, want %q", meta.ETag, `"v7"`)
+	}
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, dav.data) {
+		t.Errorf("store = %q, want %q", data, dav.data)
+	}
+}
+
+func TestWebDAVRejectedCredentials(t *testing.T) {
+	dav := &fakeDAV{}
+	w, cfg := newTestWebDAV(t, dav)
+
+	username, password, err := config.EncryptCredentials(testDAVUser, "wrong", testMasterPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Sync.WebDAV.EncryptedUsername, cfg.Sync.WebDAV.EncryptedPassword = username, password
+	w.username, w.password = "", ""
+
+	if _, err := w.Metadata(); err == nil || errors.Is(err, ErrNoRemote) {
+		t.Errorf("Metadata with wrong password: err = %v, want rejected credentials", err)
+	}
+}
+
+func TestPushAfterRemoteDeleted(t *testing.T) {
+	dav := &fakeDAV{}
+	w, cfg := newTestWebDAV(t, dav)
+	storePath := writeTestStore(t, "local")
+
+	// The last sync recorded an ETag, then the remote copy was deleted
+	cfg.Sync.Etag = `"v3"`
+	manager := &SyncManager{storePath: storePath, config: cfg, provider: w}
+	meta, err := manager.push()
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if got := dav.put.Get("If-None-Match"); got != "*" {
+		t.Errorf("If-None-Match = %q, want *", got)
+	}
+	if meta.ETag != dav.etag || string(dav.data) != "local" {
+		t.Errorf("after push: ETag = %q, remote = %q", meta.ETag, dav.data)
+	}
+}
@@ -0,0 +1,207 @@
+// Package totp implements time-based one-time passwords (RFC 6238) for
+// two-factor authentication seeds kept in the store, including parsing of
+// otpauth:// key URIs.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Algorithm is the HMAC hash function used to compute codes.
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"   // Default algorithm, supported by all authenticators
+	SHA256 Algorithm = "SHA256" // HMAC-SHA-256
+	SHA512 Algorithm = "SHA512" // HMAC-SHA-512
+)
+
+// Params describes how codes are generated from a seed.
+type Params struct {
+	// Algorithm is the HMAC hash function
+	Algorithm Algorithm `json:"algorithm"`
+	// Digits is the code length (6 or 8)
+	Digits int `json:"digits"`
+	// Period is the code lifetime in seconds
+	Period int `json:"period"`
+	// Issuer is the service that issued the seed (optional)
+	Issuer string `json:"issuer,omitempty"`
+	// Account is the account name the seed belongs to (optional)
+	Account string `json:"account,omitempty"`
+}
+
+// DefaultParams returns the parameters used by most services:
+// SHA1, 6 digits and a 30-second period.
+func DefaultParams() Params {
+	return Params{Algorithm: SHA1, Digits: 6, Period: 30}
+}
+
+// Validate checks that the parameters are supported.
+func (p Params) Validate() error {
+	if _, err := p.Algorithm.hash(); err != nil {
+		return err
+	}
+	if p.Digits != 6 && p.Digits != 8 {
+		return fmt.Errorf("unsupported number of digits %d (use 6 or 8)", p.Digits)
+	}
+	if p.Period <= 0 {
+		return fmt.Errorf("period must be positive")
+	}
+	return nil
+}
+
+// hash returns the hash constructor for the algorithm.
+func (a Algorithm) hash() (func() hash.Hash, error) {
+	switch Algorithm(strings.ToUpper(string(a))) {
+	case SHA1:
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm '%s' (use SHA1, SHA256 or SHA512)", a)
+	}
+}
+
+// NormalizeSeed validates a base32 seed and returns it in canonical form:
+// upper case, without spaces or padding.
+//
+// Parameters:
+//   - seed: The base32-encoded seed as shown by the service
+//
+// Returns:
+//   - string: The normalized seed
+//   - error: An error if the seed is not valid base32
+func NormalizeSeed(seed string) (string, error) {
+	seed = strings.ToUpper(strings.Join(strings.Fields(seed), ""))
+	seed = strings.TrimRight(seed, "=")
+	if seed == "" {
+		return "", fmt.Errorf("seed cannot be empty")
+	}
+	if _, err := decodeSeed(seed); err != nil {
+		return "", fmt.Errorf("seed is not valid base32: %v", err)
+	}
+	return seed, nil
+}
+
+// decodeSeed decodes an unpadded base32 seed.
+func decodeSeed(seed string) ([]byte, error) {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(seed)
+}
+
+// Generate computes the code for the given seed at time t.
+//
+// Parameters:
+//   - seed: The normalized base32 seed
+//   - params: The code generation parameters
+//   - t: The time to generate the code for
+//
+// Returns:
+//   - string: The zero-padded code
+//   - error: Any error that occurred while decoding the seed or validating parameters
+func Generate(seed string, params Params, t time.Time) (string, error) {
+	if err := params.Validate(); err != nil {
+		return "", err
+	}
+	newHash, _ := params.Algorithm.hash()
+
+	key, err := decodeSeed(seed)
+	if err != nil {
+		return "", fmt.Errorf("seed is not valid base32: %v", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/int64(params.Period)))
+
+	mac := hmac.New(newHash, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226, section 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < params.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", params.Digits, code%mod), nil
+}
+
+// Remaining returns how long the code generated at time t stays valid.
+func Remaining(params Params, t time.Time) time.Duration {
+	period := int64(params.Period)
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+// ParseURI parses an otpauth://totp/ key URI as produced by QR codes.
+//
+// Parameters:
+//   - uri: The key URI, e.g. otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP&issuer=Example
+//
+// Returns:
+//   - string: The normalized seed
+//   - Params: The code generation parameters (defaults applied)
+//   - error: Any error that occurred during parsing or validation
+func ParseURI(uri string) (string, Params, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", Params{}, fmt.Errorf("invalid otpauth URI: %v", err)
+	}
+	if u.Scheme != "otpauth" {
+		return "", Params{}, fmt.Errorf("invalid otpauth URI: unexpected scheme '%s'", u.Scheme)
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return "", Params{}, fmt.Errorf("unsupported OTP type '%s' (only totp is supported)", u.Host)
+	}
+
+	params := DefaultParams()
+	query := u.Query()
+
+	// The label is "issuer:account" or just "account"
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		params.Issuer = strings.TrimSpace(issuer)
+		params.Account = strings.TrimSpace(account)
+	} else {
+		params.Account = label
+	}
+	if issuer := query.Get("issuer"); issuer != "" {
+		params.Issuer = issuer
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		params.Algorithm = Algorithm(strings.ToUpper(algorithm))
+	}
+	if digits := query.Get("digits"); digits != "" {
+		if params.Digits, err = strconv.Atoi(digits); err != nil {
+			return "", Params{}, fmt.Errorf("invalid digits '%s'", digits)
+		}
+	}
+	if period := query.Get("period"); period != "" {
+		if params.Period, err = strconv.Atoi(period); err != nil {
+			return "", Params{}, fmt.Errorf("invalid period '%s'", period)
+		}
+	}
+	if err := params.Validate(); err != nil {
+		return "", Params{}, err
+	}
+
+	seed, err := NormalizeSeed(query.Get("secret"))
+	if err != nil {
+		return "", Params{}, err
+	}
+	return seed, params, nil
+}
@@ -13,10 +13,13 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/atotto/clipboard"
+	"github.com/kirinyoku/vlxck/internal/search"
 	"github.com/kirinyoku/vlxck/internal/store"
 	"github.com/manifoldco/promptui"
 	"golang.org/x/term"
@@ -58,6 +61,7 @@ func GeneratePassword(length int, useSymbols, useNumbers bool) (string, error) {
 
 // PromptForPassword prompts the user for a password and returns it as a string.
 // It reads the password from the standard input without echoing it to the screen.
+// The prompt is written to standard error.
 //
 // Parameters:
 //   - prompt: The prompt message to display to the user
@@ -65,26 +69,28 @@ func GeneratePassword(length int, useSymbols, useNumbers bool) (string, error) {
 // Returns:
 //   - string: The password entered by the user
 func PromptForPassword(prompt string) string {
-	fmt.Print(prompt)
+	// The prompt goes to stderr so that stdout stays clean for --output
+	fmt.Fprint(os.Stderr, prompt)
 	password, _ := term.ReadPassword(int(os.Stdin.Fd()))
-	fmt.Println()
+	fmt.Fprintln(os.Stderr)
 	return strings.TrimSpace(string(password))
 }
 
-// PromptForConflictChoice prompts the user for a choice when a conflict is detected between a local secret and an imported secret.
+// PromptForConflictChoice prompts the user for a choice when a conflict is detected between a local secret and an incoming secret,
+// i.e. an imported secret or a secret changed on another device.
 // It displays the details of both secrets and allows the user to choose how to resolve the conflict.
 //
 // Parameters:
 //   - localSecret: The local secret with the conflict
-//   - importedSecret: The imported secret with the conflict
+//   - incomingSecret: The imported or remote secret with the conflict
 //
 // Returns:
-//   - string: The user's choice ('l' for local, 'i' for imported, 's' for skip)
-func PromptForConflictChoice(localSecret, importedSecret store.Secret) string {
+//   - string: The user's choice ('l' for local, 'i' for incoming, 's' for skip)
+func PromptForConflictChoice(localSecret, incomingSecret store.Secret) string {
 	fmt.Printf("Conflict detected for secret name '%s':\n", localSecret.Name)
-	fmt.Printf("Local secret: Value=%s, Category=%s\n", localSecret.Value, localSecret.Category)
-	fmt.Printf("Imported secret: Value=%s, Category=%s\n", importedSecret.Value, importedSecret.Category)
-	fmt.Printf("Choose action: [l] keep local, [i] use imported, [s] skip: ")
+	fmt.Printf("Local secret: Value=%s, Category=%s, Username=%s, Updated=%s\n", localSecret.Value, localSecret.Category, localSecret.Username, localSecret.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Incoming secret: Value=%s, Category=%s, Username=%s, Updated=%s\n", incomingSecret.Value, incomingSecret.Category, incomingSecret.Username, incomingSecret.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Choose action: [l] keep local, [i] use incoming, [s] skip: ")
 
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
@@ -99,6 +105,68 @@ func PromptForConflictChoice(localSecret, importedSecret store.Secret) string {
 	return ""
 }
 
+// StdinIsTerminal reports whether standard input is a terminal, i.e. whether
+// the user can be asked questions.
+func StdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// PromptForSyncConflict asks which version of a secret changed differently
+// locally and on the remote to keep. Unlike PromptForConflictChoice, it
+// writes to standard error and never shows secret values, so that nothing
+// ends up in the output of the command that triggered the sync.
+//
+// Parameters:
+//   - local: The local version of the secret
+//   - remote: The remote version of the secret
+//
+// Returns:
+//   - bool: True to keep the remote version, false to keep the local one
+//   - error: An error if no choice could be read, e.g. at the end of input
+func PromptForSyncConflict(local, remote store.Secret) (bool, error) {
+	fmt.Fprintf(os.Stderr, "Conflict: secret '%s' was changed both locally and on the remote.\n", local.Name)
+	fmt.Fprintf(os.Stderr, "  Differs in: %s\n", strings.Join(differingFields(local, remote), ", "))
+	fmt.Fprintf(os.Stderr, "  Local:  updated %s\n", local.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(os.Stderr, "  Remote: updated %s\n", remote.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprint(os.Stderr, "Keep [l] local or [r] remote version: ")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "l":
+			return false, nil
+		case "r":
+			return true, nil
+		}
+		fmt.Fprint(os.Stderr, "Invalid choice. Please enter [l] or [r]: ")
+	}
+	fmt.Fprintln(os.Stderr)
+	return false, fmt.Errorf("no choice made for the conflict on secret '%s'", local.Name)
+}
+
+// differingFields names the fields in which two versions of a secret differ,
+// without revealing their contents.
+func differingFields(a, b store.Secret) []string {
+	var fields []string
+	add := func(name string, differs bool) {
+		if differs {
+			fields = append(fields, name)
+		}
+	}
+	add("value", a.Value != b.Value)
+	add("category", a.Category != b.Category)
+	add("tags", strings.Join(a.Tags, ",") != strings.Join(b.Tags, ","))
+	add("username", a.Username != b.Username)
+	add("urls", strings.Join(a.URLs, ",") != strings.Join(b.URLs, ","))
+	add("notes", a.Notes != b.Notes)
+	add("custom fields", len(a.Fields)+len(b.Fields) > 0 && !reflect.DeepEqual(a.Fields, b.Fields))
+	add("totp", !reflect.DeepEqual(a.TOTP, b.TOTP))
+	if len(fields) == 0 {
+		fields = append(fields, "history")
+	}
+	return fields
+}
+
 // CopyToClipboard copies the specified text to the clipboard.
 //
 // Parameters:
@@ -278,13 +346,101 @@ func PromptForSecretValue() (string, error) {
 }
 
 // PromptForCategory prompts the user for a category using the promptui library.
-// It displays a label and allows the user to enter a value.
+// Categories are folder paths such as "work/aws/prod"; the result is normalized.
 //
 // Returns:
 //   - string: The category entered by the user
 //   - error: Any error that occurred during the input operation
 func PromptForCategory() (string, error) {
-	return PromptForInput("Enter category (optional)", "", nil)
+	category, err := PromptForInput("Enter category folder, e.g. work/aws (optional)", "", nil)
+	if err != nil {
+		return "", err
+	}
+	return store.NormalizeFolder(category), nil
+}
+
+// PromptForTags prompts the user for a comma-separated list of tags.
+//
+// Parameters:
+//   - current: The current tags, shown as the default value
+//
+// Returns:
+//   - []string: The normalized tags entered by the user
+//   - error: Any error that occurred during the input operation
+func PromptForTags(current []string) ([]string, error) {
+	input, err := PromptForInput("Enter tags (comma-separated, optional)", strings.Join(current, ", "), nil)
+	if err != nil {
+		return nil, err
+	}
+	return store.NormalizeTags(SplitList(input)), nil
+}
+
+// PromptForURLs prompts the user for a comma-separated list of URLs.
+//
+// Parameters:
+//   - current: The current URLs, shown as the default value
+//
+// Returns:
+//   - []string: The URLs entered by the user (empty entries are dropped)
+//   - error: Any error that occurred during the input operation
+func PromptForURLs(current []string) ([]string, error) {
+	input, err := PromptForInput("Enter URLs (comma-separated, optional)", strings.Join(current, ", "), nil)
+	if err != nil {
+		return nil, err
+	}
+	return SplitList(input), nil
+}
+
+// PromptForCustomField prompts the user for the name, type and value of a custom field.
+//
+// Returns:
+//   - store.CustomField: The validated custom field
+//   - error: Any error that occurred during the input operation
+func PromptForCustomField() (store.CustomField, error) {
+	name, err := PromptForInput("Enter field name", "", func(input string) error {
+		if input == "" {
+			return fmt.Errorf("name cannot be empty")
+		}
+		return nil
+	})
+	if err != nil {
+		return store.CustomField{}, err
+	}
+
+	types := make([]string, len(store.FieldTypes))
+	for i, t := range store.FieldTypes {
+		types[i] = string(t)
+	}
+	fieldType, err := PromptForSelect("Select field type", types)
+	if err != nil {
+		return store.CustomField{}, err
+	}
+
+	value, err := PromptForInput("Enter field value", "", func(input string) error {
+		return store.CustomField{Name: name, Type: store.FieldType(fieldType), Value: input}.Validate()
+	})
+	if err != nil {
+		return store.CustomField{}, err
+	}
+
+	return store.CustomField{Name: name, Type: store.FieldType(fieldType), Value: value}, nil
+}
+
+// SplitList splits a comma-separated string into trimmed, non-empty items.
+//
+// Parameters:
+//   - input: The comma-separated string
+//
+// Returns:
+//   - []string: The list of items
+func SplitList(input string) []string {
+	var items []string
+	for _, item := range strings.Split(input, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // PromptForConfirm prompts the user for a yes/no confirmation.
@@ -312,33 +468,85 @@ func PromptForConfirm(prompt string) (bool, error) {
 	return true, nil
 }
 
-// PromptForSecret prompts the user for a secret using the promptui library.
-// It displays a label and allows the user to select a secret from a list.
+// PromptForSecret prompts the user to select a secret using the promptui library.
+// The selector starts in search mode: typing filters and ranks the secrets with
+// the same fuzzy matching as the search command (name, category, username and URL).
 //
 // Parameters:
-//   - secrets: The list of secrets to display in the selection menu
+//   - label: The label to display to the user
+//   - secrets: The list of secrets to choose from
 //
 // Returns:
 //   - store.Secret: The selected secret
 //   - error: Any error that occurred during the selection operation
-func PromptForSecret(secrets []store.Secret) (store.Secret, error) {
+func PromptForSecret(label string, secrets []store.Secret) (store.Secret, error) {
 	if len(secrets) == 0 {
 		return store.Secret{}, fmt.Errorf("no secrets available")
 	}
-	items := make([]string, len(secrets))
-	for i, secret := range secrets {
-		items[i] = fmt.Sprintf("%s (%s)", secret.Name, secret.Category)
+
+	slots := make([]*secretSlot, len(secrets))
+	for i := range secrets {
+		slots[i] = &secretSlot{secret: &secrets[i], matched: true}
 	}
-	choice, err := PromptForSelect("Select secret", items)
+
+	prompt := promptui.Select{
+		Label: label,
+		Items: slots,
+		Size:  10,
+		// promptui only filters items, so the slots are refilled in ranked
+		// order whenever the search term changes (the searcher is called
+		// for every item, starting at index 0).
+		Searcher: func(input string, index int) bool {
+			if index == 0 {
+				rankSlots(slots, secrets, input)
+			}
+			return slots[index].matched
+		},
+		StartInSearchMode: true,
+	}
+	idx, _, err := prompt.Run()
 	if err != nil {
-		return store.Secret{}, err
+		return store.Secret{}, fmt.Errorf("select failed: %v", err)
+	}
+	return *slots[idx].secret, nil
+}
+
+// secretSlot is a position in the secret selector. Its content is replaced
+// as the search term changes.
+type secretSlot struct {
+	secret  *store.Secret
+	matched bool
+}
+
+// String renders the slot as "name  [category]  username".
+func (s *secretSlot) String() string {
+	label := s.secret.Name
+	if s.secret.Category != "" {
+		label += "  [" + s.secret.Category + "]"
+	}
+	if s.secret.Username != "" {
+		label += "  " + s.secret.Username
 	}
-	for _, secret := range secrets {
-		if strings.HasPrefix(choice, secret.Name) {
-			return secret, nil
+	return label
+}
+
+// rankSlots fills the slots with the secrets matching the query, best first,
+// followed by the non-matching secrets in their original order.
+func rankSlots(slots []*secretSlot, secrets []store.Secret, query string) {
+	order := search.Order(secrets, query)
+	matched := make([]bool, len(secrets))
+	for _, idx := range order {
+		matched[idx] = true
+	}
+	for i := range secrets {
+		if !matched[i] {
+			order = append(order, i)
 		}
 	}
-	return store.Secret{}, fmt.Errorf("selected secret not found")
+	for i, idx := range order {
+		slots[i].secret = &secrets[idx]
+		slots[i].matched = matched[idx]
+	}
 }
 
 // PromptForCategoryFilter prompts the user for a category filter using the promptui library.
@@ -434,3 +642,44 @@ func DecryptFile(data []byte, password string) ([]byte, error) {
 
 	return aesgcm.Open(nil, nonce, ciphertext, nil)
 }
+
+// WriteFileAtomic writes data to a file so that readers see either the old
+// content or the new content, never a partial write. The data is written to a
+// temporary file in the same directory, synced and renamed over the target.
+//
+// Parameters:
+//   - path: The file to write
+//   - data: The content to write
+//   - perm: The permissions of the file (e.g. 0600)
+//
+// Returns:
+//   - error: Any error that occurred while writing the file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	tmpPath := tmp.Name()
+	// Clean up the temporary file unless it was renamed into place
+	defer os.Remove(tmpPath)
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set permissions: %v", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}